@@ -3,13 +3,16 @@ module github.com/devtron-labs/deprecation-checker
 go 1.15
 
 require (
+	github.com/docker/spdystream v0.0.0-20160310174837-449fdfce4d96 // indirect
 	github.com/fatih/color v1.10.0
 	github.com/getkin/kin-openapi v0.67.0
+	github.com/google/cel-go v0.12.6
 	github.com/hashicorp/go-multierror v1.1.1
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
+	github.com/mgutz/ansi v0.0.0-20200706080929-d51e80ef957d
 	github.com/olekukonko/tablewriter v0.0.5 // indirect
-	github.com/prometheus/common v0.4.0
-	github.com/spf13/cobra v0.0.0-20180820174524-ff0d02e85550
+	github.com/prometheus/common v0.10.0
+	github.com/spf13/cobra v1.1.3
 	github.com/spf13/viper v1.7.1
 	github.com/stretchr/testify v1.7.0
 	github.com/tidwall/gjson v1.8.1 // indirect
@@ -17,8 +20,16 @@ require (
 	github.com/tomlazar/table v0.1.0
 	github.com/xeipuuv/gojsonpointer v0.0.0-20180127040702-4e3ac2762d5f // indirect
 	github.com/xeipuuv/gojsonreference v0.0.0-20180127040603-bd5ef7bd5415 // indirect
-	github.com/xeipuuv/gojsonschema v0.0.0-20180816142147-da425ebb7609
+	github.com/xeipuuv/gojsonschema v1.2.0
+	go.etcd.io/bbolt v1.3.5
+	go.etcd.io/etcd/api/v3 v3.5.0
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
+	helm.sh/helm/v3 v3.6.3
+	k8s.io/api v0.21.0
+	k8s.io/apiextensions-apiserver v0.21.0
 	k8s.io/apimachinery v0.22.0
+	k8s.io/client-go v0.21.0
+	sigs.k8s.io/kustomize/api v0.8.11
+	sigs.k8s.io/kustomize/kyaml v0.11.0
 	sigs.k8s.io/yaml v1.2.0
-	k8s.io/client-go v0.20.4
 )
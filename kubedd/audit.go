@@ -0,0 +1,32 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package kubedd
+
+import (
+	"github.com/devtron-labs/deprecation-checker/pkg"
+)
+
+// AnalyzeAuditLogs reports the requests of the apiserver audit logs fileNames
+// to apis which are deprecated or not served by the target kubernetes
+// version. Clients keep calling old endpoints even when the objects are
+// stored at new versions, which only the audit logs show
+func AnalyzeAuditLogs(fileNames []string, conf *pkg.Config) ([]pkg.AuditUsage, error) {
+	kubeC := pkg.NewKubeCheckerImpl()
+	loadSchema(kubeC, conf.TargetKubernetesVersion, conf.TargetSchemaLocation)
+	return pkg.AnalyzeAuditLogs(fileNames, kubeC, conf.TargetKubernetesVersion)
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package kubedd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/devtron-labs/deprecation-checker/pkg"
+)
+
+const (
+	// backupResourcesDir is the directory of a velero backup tarball holding
+	// the backed up objects
+	backupResourcesDir = "resources"
+	// preferredVersionSuffix marks the versioned directory of the preferred
+	// version of a group, written along with the others if velero backs up all
+	// the versions of a group
+	preferredVersionSuffix = "-preferredversion"
+	clusterScopeDir        = "cluster"
+	namespaceScopeDir      = "namespaces"
+)
+
+// BackupResult are the results of the objects of a velero backup
+type BackupResult struct {
+	Name    string
+	Results []pkg.ValidationResult
+}
+
+// ValidateVeleroBackups validates the objects of the velero backup tarballs
+// fileNames, the archives are streamed rather than extracted. The objects are
+// validated against the target kubernetes version, into which they would be
+// restored
+func ValidateVeleroBackups(fileNames []string, conf *pkg.Config) ([]BackupResult, error) {
+	kubeC := newKubeChecker(conf)
+	var backupResults []BackupResult
+	for _, fileName := range fileNames {
+		m, err := readVeleroBackup(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("could not read backup %s: %v", fileName, err)
+		}
+		backupResults = append(backupResults, BackupResult{
+			Name:    backupName(fileName),
+			Results: validateManifests(kubeC, m, conf),
+		})
+	}
+	return backupResults, nil
+}
+
+// RemovedAPIs returns the kinds and apiVersions of the objects of the backup
+// which are removed in the target kubernetes version, grouped by the namespace
+// velero stored them in. Cluster scoped objects are grouped under the empty
+// namespace
+func (b BackupResult) RemovedAPIs() map[string][]string {
+	removed := map[string]map[string]bool{}
+	for _, result := range b.Results {
+		if !result.Deleted {
+			continue
+		}
+		_, namespace, _, _ := backupObjectPath(result.FileName)
+		if removed[namespace] == nil {
+			removed[namespace] = map[string]bool{}
+		}
+		removed[namespace][fmt.Sprintf("%s %s", result.Kind, result.APIVersion)] = true
+	}
+	apis := map[string][]string{}
+	for namespace, kinds := range removed {
+		for kind := range kinds {
+			apis[namespace] = append(apis[namespace], kind)
+		}
+		sort.Strings(apis[namespace])
+	}
+	return apis
+}
+
+// readVeleroBackup reads the objects of the gzipped velero backup tarball
+// fileName
+func readVeleroBackup(fileName string) (*manifests, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	return readBackupArchive(tar.NewReader(gz))
+}
+
+// readBackupArchive reads the objects stored as
+// resources/<group-resource>/[namespaces/<ns>|cluster]/<name>.json in archive.
+// Of the versioned directories, which velero writes for every version of a
+// group if asked to, only the preferred version is read and only if the
+// object is not stored in the unversioned directories as well
+func readBackupArchive(archive *tar.Reader) (*manifests, error) {
+	m := &manifests{}
+	read := map[string]bool{}
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return m, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		key, ok := backupObjectKey(header.Name)
+		if !ok || read[key] {
+			continue
+		}
+		read[key] = true
+		data, err := ioutil.ReadAll(archive)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.add(strings.TrimPrefix(header.Name, "./"), data); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// backupObjectKey returns the group resource, namespace and name of the object
+// stored at path of a backup tarball, if path stores an object which is read
+func backupObjectKey(path string) (string, bool) {
+	groupResource, namespace, name, ok := backupObjectPath(path)
+	if !ok {
+		return "", false
+	}
+	if len(namespace) == 0 {
+		return strings.Join([]string{groupResource, name}, "/"), true
+	}
+	return strings.Join([]string{groupResource, namespace, name}, "/"), true
+}
+
+// backupObjectPath splits the path of an object stored in a backup tarball
+// into its group resource, namespace, empty for cluster scoped objects, and
+// file name, if path stores an object which is read
+func backupObjectPath(path string) (groupResource, namespace, name string, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(path, "./"), "/")
+	if len(parts) < 4 || parts[0] != backupResourcesDir || filepath.Ext(path) != ".json" {
+		return "", "", "", false
+	}
+	groupResource, scope := parts[1], parts[2:]
+	if scope[0] != clusterScopeDir && scope[0] != namespaceScopeDir {
+		if !strings.HasSuffix(scope[0], preferredVersionSuffix) {
+			return "", "", "", false
+		}
+		scope = scope[1:]
+	}
+	switch {
+	case len(scope) == 2 && scope[0] == clusterScopeDir:
+		return groupResource, "", scope[1], true
+	case len(scope) == 3 && scope[0] == namespaceScopeDir:
+		return groupResource, scope[1], scope[2], true
+	}
+	return "", "", "", false
+}
+
+// backupName returns the name of the backup stored in the tarball fileName,
+// velero names the tarballs after their backup
+func backupName(fileName string) string {
+	name := filepath.Base(fileName)
+	for _, ext := range []string{".tar.gz", ".tgz", ".gz", ".tar"} {
+		if strings.HasSuffix(name, ext) {
+			return strings.TrimSuffix(name, ext)
+		}
+	}
+	return name
+}
@@ -0,0 +1,104 @@
+package kubedd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const (
+	backupDeployment        = `{"apiVersion": "extensions/v1beta1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "apps"}}`
+	backupLatestDeployment  = `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "apps"}, "spec": {"selector": {"matchLabels": {"app": "web"}}, "template": {"metadata": {"labels": {"app": "web"}}, "spec": {"containers": [{"name": "web", "image": "nginx"}]}}}}`
+	backupNamespace         = `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "apps"}}`
+	backupPodSecurityPolicy = `{"apiVersion": "policy/v1beta1", "kind": "PodSecurityPolicy", "metadata": {"name": "restricted"}, "spec": {}}`
+)
+
+// writeBackup writes files to a gzipped tarball as velero does
+func writeBackup(t *testing.T, fileName string, files [][2]string) {
+	file, err := os.Create(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	archive := tar.NewWriter(gz)
+	for _, f := range files {
+		if err := archive.WriteHeader(&tar.Header{Name: f[0], Mode: 0644, Size: int64(len(f[1])), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write([]byte(f[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_backupObjectKey(t *testing.T) {
+	tests := []struct {
+		path   string
+		want   string
+		wantOk bool
+	}{
+		{"resources/deployments.apps/namespaces/apps/web.json", "deployments.apps/apps/web.json", true},
+		{"./resources/namespaces/cluster/apps.json", "namespaces/apps.json", true},
+		{"resources/deployments.apps/v1-preferredversion/namespaces/apps/web.json", "deployments.apps/apps/web.json", true},
+		{"resources/deployments.apps/v1beta2/namespaces/apps/web.json", "", false},
+		{"metadata/version", "", false},
+		{"resources/deployments.apps/namespaces/apps", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := backupObjectKey(tt.path)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("backupObjectKey() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestValidateVeleroBackups(t *testing.T) {
+	dir := t.TempDir()
+	nightly := filepath.Join(dir, "nightly-20211001.tar.gz")
+	writeBackup(t, nightly, [][2]string{
+		{"metadata/version", "1"},
+		{"resources/namespaces/cluster/apps.json", backupNamespace},
+		{"resources/deployments.extensions/namespaces/apps/web.json", backupDeployment},
+		{"resources/deployments.extensions/v1beta1-preferredversion/namespaces/apps/web.json", backupDeployment},
+		{"resources/deployments.apps/v1/namespaces/apps/web.json", backupLatestDeployment},
+		{"resources/podsecuritypolicies.policy/cluster/restricted.json", backupPodSecurityPolicy},
+	})
+	weekly := filepath.Join(dir, "weekly.tar.gz")
+	writeBackup(t, weekly, [][2]string{
+		{"resources/deployments.apps/namespaces/apps/web.json", backupLatestDeployment},
+	})
+	conf := testConfig()
+	conf.TargetSchemaLocation = "testdata/target-swagger.json"
+	backupResults, err := ValidateVeleroBackups([]string{nightly, weekly}, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backupResults) != 2 || backupResults[0].Name != "nightly-20211001" || backupResults[1].Name != "weekly" {
+		t.Fatalf("ValidateVeleroBackups() = %v, want a result per backup", backupResults)
+	}
+	if n := len(backupResults[0].Results); n != 3 {
+		t.Errorf("ValidateVeleroBackups() = %d results, want the namespace, a single deployment and the pod security policy", n)
+	}
+	want := map[string][]string{"apps": {"Deployment extensions/v1beta1"}, "": {"PodSecurityPolicy policy/v1beta1"}}
+	if got := backupResults[0].RemovedAPIs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("RemovedAPIs() = %v, want %v", got, want)
+	}
+	if got := backupResults[1].RemovedAPIs(); len(got) != 0 {
+		t.Errorf("RemovedAPIs() = %v, want none", got)
+	}
+	if _, err := ValidateVeleroBackups([]string{filepath.Join(dir, "missing.tar.gz")}, conf); err == nil {
+		t.Error("ValidateVeleroBackups() of a missing backup should fail")
+	}
+}
@@ -0,0 +1,177 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package kubedd
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/devtron-labs/deprecation-checker/pkg"
+	kLog "github.com/devtron-labs/deprecation-checker/pkg/log"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/engine"
+)
+
+// ChartOptions are the values and release a chart is rendered with
+type ChartOptions struct {
+	// ValueFiles are the values files, merged in order
+	ValueFiles []string
+	// Values are the values set as key=value pairs, as helm --set does
+	Values []string
+	// StringValues are the values set as strings, as helm --set-string does
+	StringValues []string
+	ReleaseName  string
+	Namespace    string
+}
+
+// ValidateChart renders the helm chart at chartPath offline, with the
+// capabilities of the target kubernetes version, and validates the rendered
+// resources. Findings are attributed to the template rendering them
+func ValidateChart(chartPath string, options ChartOptions, conf *pkg.Config) ([]pkg.ValidationResult, error) {
+	chrt, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	kubeC := newKubeChecker(conf)
+	caps, err := chartCapabilities(kubeC, conf.TargetKubernetesVersion)
+	if err != nil {
+		return nil, err
+	}
+	var results []pkg.ValidationResult
+	if result := checkKubeVersion(chrt, caps); result != nil {
+		results = append(results, *result)
+	}
+	rendered, err := renderChart(chrt, options, caps, conf)
+	if err != nil {
+		return nil, err
+	}
+	m := &manifests{}
+	for _, crd := range chrt.CRDObjects() {
+		if err := m.add(crd.Filename, crd.File.Data); err != nil {
+			return nil, err
+		}
+	}
+	templates := make([]string, 0, len(rendered))
+	for name := range rendered {
+		templates = append(templates, name)
+	}
+	sort.Strings(templates)
+	for _, name := range templates {
+		if strings.HasSuffix(name, "NOTES.txt") || len(strings.TrimSpace(rendered[name])) == 0 {
+			continue
+		}
+		if err := m.add(name, []byte(rendered[name])); err != nil {
+			return nil, err
+		}
+	}
+	return append(results, validateManifests(kubeC, m, conf)...), nil
+}
+
+// chartCapabilities are the capabilities of targetVersion, every group version
+// and group version kind it serves is available to the templates
+func chartCapabilities(kubeC pkg.KubeChecker, targetVersion string) (*chartutil.Capabilities, error) {
+	caps := chartutil.DefaultCapabilities.Copy()
+	if kubeVersion, err := chartutil.ParseKubeVersion(targetVersion); err == nil {
+		caps.KubeVersion = *kubeVersion
+	} else {
+		kLog.Warn(fmt.Sprintf("rendering charts for kubernetes %s as target version %s is not a release", caps.KubeVersion.Version, targetVersion))
+	}
+	gvks, err := kubeC.GetServedKinds(targetVersion)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	apiVersions := chartutil.VersionSet{}
+	for _, gvk := range gvks {
+		groupVersion := gvk.GroupVersion().String()
+		for _, version := range []string{groupVersion, fmt.Sprintf("%s/%s", groupVersion, gvk.Kind)} {
+			if !seen[version] {
+				seen[version] = true
+				apiVersions = append(apiVersions, version)
+			}
+		}
+	}
+	sort.Strings(apiVersions)
+	caps.APIVersions = apiVersions
+	return caps, nil
+}
+
+// checkKubeVersion reports the kubeVersion constraint of chrt if the target
+// kubernetes version does not satisfy it, as helm would refuse to install it
+func checkKubeVersion(chrt *chart.Chart, caps *chartutil.Capabilities) *pkg.ValidationResult {
+	constraint := chrt.Metadata.KubeVersion
+	if len(constraint) == 0 || chartutil.IsCompatibleRange(constraint, caps.KubeVersion.String()) {
+		return nil
+	}
+	fileName := path.Join(chrt.Name(), chartutil.ChartfileName)
+	position := &pkg.Position{File: fileName, Line: 1, Column: 1}
+	for _, file := range chrt.Raw {
+		if file.Name != chartutil.ChartfileName {
+			continue
+		}
+		if sourceMap, err := pkg.ParseSourceMap(fileName, 0, file.Data, 0); err == nil {
+			position = sourceMap.Lookup([]string{"kubeVersion"})
+		}
+	}
+	return &pkg.ValidationResult{
+		FileName: fileName,
+		Position: position,
+		ErrorsForOriginal: []*pkg.SchemaError{{
+			Reason:   fmt.Sprintf("chart requires kubeVersion %s which is incompatible with kubernetes %s", constraint, caps.KubeVersion.String()),
+			Category: pkg.CategoryKubeVersion,
+			Code:     pkg.CategoryKubeVersion.Code(),
+			Expected: constraint,
+			Actual:   caps.KubeVersion.String(),
+			Position: position,
+		}},
+	}
+}
+
+// renderChart renders the templates of chrt as helm install would
+func renderChart(chrt *chart.Chart, options ChartOptions, caps *chartutil.Capabilities, conf *pkg.Config) (map[string]string, error) {
+	valueOptions := values.Options{ValueFiles: options.ValueFiles, Values: options.Values, StringValues: options.StringValues}
+	vals, err := valueOptions.MergeValues(nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := chartutil.ProcessDependencies(chrt, vals); err != nil {
+		return nil, err
+	}
+	releaseOptions := chartutil.ReleaseOptions{
+		Name:      options.ReleaseName,
+		Namespace: options.Namespace,
+		Revision:  1,
+		IsInstall: true,
+	}
+	if len(releaseOptions.Name) == 0 {
+		releaseOptions.Name = chrt.Name()
+	}
+	if len(releaseOptions.Namespace) == 0 {
+		releaseOptions.Namespace = conf.DefaultNamespace
+	}
+	renderValues, err := chartutil.ToRenderValues(chrt, vals, releaseOptions, caps)
+	if err != nil {
+		return nil, err
+	}
+	return engine.Render(chrt, renderValues)
+}
@@ -0,0 +1,91 @@
+package kubedd
+
+import (
+	"testing"
+
+	"github.com/devtron-labs/deprecation-checker/pkg"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// testConfig validates against the trimmed down spec of pkg/testdata
+func testConfig() *pkg.Config {
+	conf := pkg.NewDefaultConfig()
+	conf.TargetKubernetesVersion = "1.22"
+	conf.SourceKubernetesVersion = "1.22"
+	conf.TargetSchemaLocation = "../pkg/testdata/swagger.json"
+	conf.SourceSchemaLocation = "../pkg/testdata/swagger.json"
+	return conf
+}
+
+func Test_chartCapabilities(t *testing.T) {
+	conf := testConfig()
+	caps, err := chartCapabilities(newKubeChecker(conf), conf.TargetKubernetesVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.KubeVersion.Version != "v1.22.0" || caps.KubeVersion.Minor != "22" {
+		t.Errorf("chartCapabilities() kube version = %v, want v1.22.0", caps.KubeVersion)
+	}
+	for version, want := range map[string]bool{
+		"v1":                            true,
+		"v1/Pod":                        true,
+		"apps/v1/Deployment":            true,
+		"extensions/v1beta1/Deployment": true,
+		"policy/v1beta1":                false,
+	} {
+		if got := caps.APIVersions.Has(version); got != want {
+			t.Errorf("chartCapabilities() APIVersions.Has(%s) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func Test_checkKubeVersion(t *testing.T) {
+	caps := chartutil.DefaultCapabilities.Copy()
+	caps.KubeVersion = chartutil.KubeVersion{Version: "v1.22.0", Major: "1", Minor: "22"}
+	tests := []struct {
+		name       string
+		constraint string
+		wantError  bool
+	}{
+		{name: "no constraint", constraint: ""},
+		{name: "compatible", constraint: ">=1.16.0-0"},
+		{name: "incompatible", constraint: "<1.22.0-0", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chrt := &chart.Chart{Metadata: &chart.Metadata{Name: "demo", KubeVersion: tt.constraint}}
+			result := checkKubeVersion(chrt, caps)
+			if (result != nil) != tt.wantError {
+				t.Fatalf("checkKubeVersion() = %v, want error %v", result, tt.wantError)
+			}
+			if result != nil && !pkg.IsErrorClass(result.ErrorsForOriginal[0], "KDD002") {
+				t.Errorf("checkKubeVersion() code = %s, want KDD002", result.ErrorsForOriginal[0].Code)
+			}
+		})
+	}
+}
+
+func TestValidateChart(t *testing.T) {
+	results, err := ValidateChart("testdata/chart", ChartOptions{Values: []string{"port=http"}}, testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the pod is only rendered if policy/v1beta1 is served
+	if len(results) != 1 {
+		t.Fatalf("ValidateChart() = %d results, want 1", len(results))
+	}
+	result := results[0]
+	if result.Kind != "Deployment" || result.APIVersion != "apps/v1" || result.ResourceName != "demo" {
+		t.Errorf("ValidateChart() = %s %s %s, want apps/v1 Deployment demo", result.APIVersion, result.Kind, result.ResourceName)
+	}
+	if result.FileName != "demo/templates/deployment.yaml" {
+		t.Errorf("ValidateChart() file = %s, want demo/templates/deployment.yaml", result.FileName)
+	}
+	if len(result.ErrorsForOriginal) != 1 || !pkg.IsErrorClass(result.ErrorsForOriginal[0], "type-mismatch") {
+		t.Fatalf("ValidateChart() errors = %v, want the type mismatch of the port", result.ErrorsForOriginal)
+	}
+	if position := result.ErrorsForOriginal[0].Position; position == nil || position.File != result.FileName || position.Line != 21 {
+		t.Errorf("ValidateChart() position = %v, want line 21 of the template", position)
+	}
+}
@@ -0,0 +1,256 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package kubedd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/devtron-labs/deprecation-checker/pkg"
+	yamlv3 "gopkg.in/yaml.v3"
+	"sigs.k8s.io/yaml"
+)
+
+// document is a yaml document of a stream, with the line of the stream it
+// starts on
+type document struct {
+	index int
+	line  int
+	data  []byte
+}
+
+// documentReader reads the documents of a yaml stream. Documents are started
+// by `---` lines, which may be followed by a comment or content, and ended by
+// `...` lines. CRLF line endings are read as LF
+type documentReader struct {
+	reader *bufio.Reader
+	line   int
+	index  int
+	// pending is the content following the separator of the next document
+	pending []byte
+	done    bool
+}
+
+func newDocumentReader(r io.Reader) *documentReader {
+	return &documentReader{reader: bufio.NewReader(r)}
+}
+
+// Next returns the next document of the stream, io.EOF once there are none
+// left. Empty documents are returned as well
+func (d *documentReader) Next() (*document, error) {
+	if d.done {
+		return nil, io.EOF
+	}
+	doc := &document{index: d.index, line: d.line + 1}
+	var buf bytes.Buffer
+	if d.pending != nil {
+		buf.Write(d.pending)
+		buf.WriteByte('\n')
+		d.pending = nil
+		// the content is on the line of the separator
+		doc.line = d.line
+	}
+	for {
+		line, err := d.reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(line) == 0 && err == io.EOF {
+			d.done = true
+			break
+		}
+		d.line++
+		line = bytes.TrimRight(line, "\r\n")
+		if separator, content := documentSeparator(line); separator {
+			if buf.Len() == 0 {
+				// a leading separator starts the document
+				doc.line = d.line + 1
+				if content != nil {
+					buf.Write(content)
+					buf.WriteByte('\n')
+					doc.line = d.line
+				}
+				continue
+			}
+			d.pending = content
+			break
+		}
+		if isDocumentEnd(line) {
+			d.skipToNextDocument()
+			break
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+		if err == io.EOF {
+			d.done = true
+			break
+		}
+	}
+	d.index++
+	doc.data = buf.Bytes()
+	return doc, nil
+}
+
+// skipToNextDocument skips the directives and comments following the end of
+// a document up to the separator of the next one, or starts the next document
+// right away if it is a bare document
+func (d *documentReader) skipToNextDocument() {
+	for {
+		peek, err := d.reader.Peek(1)
+		if err != nil || len(peek) == 0 {
+			d.done = true
+			return
+		}
+		line, _ := d.reader.ReadBytes('\n')
+		d.line++
+		line = bytes.TrimRight(line, "\r\n")
+		trimmed := bytes.TrimSpace(line)
+		if separator, content := documentSeparator(line); separator {
+			d.pending = content
+			return
+		}
+		if len(trimmed) == 0 || trimmed[0] == '#' || trimmed[0] == '%' {
+			continue
+		}
+		d.pending = line
+		return
+	}
+}
+
+// documentSeparator checks whether line starts a document and returns the
+// content following the separator, if any other than a comment
+func documentSeparator(line []byte) (bool, []byte) {
+	if !bytes.HasPrefix(line, []byte("---")) {
+		return false, nil
+	}
+	rest := line[3:]
+	if len(rest) > 0 && rest[0] != ' ' && rest[0] != '\t' {
+		return false, nil
+	}
+	rest = bytes.TrimSpace(rest)
+	if len(rest) == 0 || rest[0] == '#' {
+		return true, nil
+	}
+	return true, append([]byte(nil), rest...)
+}
+
+func isDocumentEnd(line []byte) bool {
+	if !bytes.HasPrefix(line, []byte("...")) {
+		return false
+	}
+	rest := bytes.TrimSpace(line[3:])
+	return len(rest) == 0 || rest[0] == '#'
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+): `)
+
+// parseDocument decodes doc into the resources it contains with its source
+// map. Documents are either an object, which is expanded if it is a list, or
+// an array of objects as json manifests often are. Empty and comment only
+// documents contain no resources
+func parseDocument(fileName string, doc *document) ([]pkg.ListItem, *pkg.SourceMap, error) {
+	var node yamlv3.Node
+	if err := yamlv3.Unmarshal(doc.data, &node); err != nil {
+		return nil, nil, documentError(fileName, doc, err)
+	}
+	if node.Kind == 0 || len(node.Content) == 0 || (node.Content[0].Kind == yamlv3.ScalarNode && node.Content[0].Tag == "!!null") {
+		return nil, nil, nil
+	}
+	sourceMap := pkg.NewSourceMap(fileName, doc.index, &node, doc.line-1)
+	jsonSpec, err := yaml.YAMLToJSON(doc.data)
+	if err != nil {
+		return nil, sourceMap, documentError(fileName, doc, err)
+	}
+	var content interface{}
+	if err := json.Unmarshal(jsonSpec, &content); err != nil {
+		return nil, sourceMap, documentError(fileName, doc, err)
+	}
+	switch v := content.(type) {
+	case map[string]interface{}:
+		return pkg.ExpandList(v), sourceMap, nil
+	case []interface{}:
+		var items []pkg.ListItem
+		for i, element := range v {
+			object, ok := element.(map[string]interface{})
+			if !ok {
+				return nil, sourceMap, locatedError(sourceMap, []string{strconv.Itoa(i)}, fmt.Errorf("item %d of the document is not an object", i))
+			}
+			for _, item := range pkg.ExpandList(object) {
+				item.Path = append([]string{strconv.Itoa(i)}, item.Path...)
+				items = append(items, item)
+			}
+		}
+		return items, sourceMap, nil
+	}
+	return nil, sourceMap, locatedError(sourceMap, nil, fmt.Errorf("document is not an object"))
+}
+
+func locatedError(sourceMap *pkg.SourceMap, path []string, err error) *pkg.ParseError {
+	return &pkg.ParseError{Position: *sourceMap.Lookup(path), Err: err}
+}
+
+// documentError locates the parse error err of doc, yaml errors tell the line
+// within the document
+func documentError(fileName string, doc *document, err error) *pkg.ParseError {
+	line := doc.line
+	if match := yamlErrorLine.FindStringSubmatch(err.Error()); match != nil {
+		if l, convErr := strconv.Atoi(match[1]); convErr == nil {
+			line = doc.line + l - 1
+		}
+	}
+	return &pkg.ParseError{
+		Position: pkg.Position{File: fileName, Document: doc.index, Line: line, Column: 1},
+		Err:      fmt.Errorf("%s", yamlErrorLine.ReplaceAllString(strings.TrimPrefix(err.Error(), "yaml: "), "")),
+	}
+}
+
+// IsJSONManifest checks whether input is a json kubernetes object, or an
+// array of them, rather than some other json file found alongside the
+// manifests, e.g. package.json. Input which is not json is not a manifest
+// either, e.g. tsconfig.json with comments
+func IsJSONManifest(input []byte) bool {
+	var value interface{}
+	if err := json.Unmarshal(input, &value); err != nil {
+		return false
+	}
+	if items, ok := value.([]interface{}); ok {
+		for _, item := range items {
+			if isJSONObject(item) {
+				return true
+			}
+		}
+		return false
+	}
+	return isJSONObject(value)
+}
+
+func isJSONObject(value interface{}) bool {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	apiVersion, _ := object["apiVersion"].(string)
+	kind, _ := object["kind"].(string)
+	return len(apiVersion) > 0 && len(kind) > 0
+}
@@ -0,0 +1,184 @@
+package kubedd
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/devtron-labs/deprecation-checker/pkg"
+)
+
+func readAll(t *testing.T, input string) []*document {
+	var docs []*document
+	reader := newDocumentReader(bytes.NewReader([]byte(input)))
+	for {
+		doc, err := reader.Next()
+		if err == io.EOF {
+			return docs
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		docs = append(docs, doc)
+	}
+}
+
+func Test_documentReader(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantData  []string
+		wantLines []int
+	}{
+		{
+			name:      "single document",
+			input:     "a: 1\nb: 2\n",
+			wantData:  []string{"a: 1\nb: 2\n"},
+			wantLines: []int{1},
+		},
+		{
+			name:      "leading separator and comments",
+			input:     "--- # first\na: 1\n---   \nb: 2\n",
+			wantData:  []string{"a: 1\n", "b: 2\n"},
+			wantLines: []int{2, 4},
+		},
+		{
+			name:      "document end",
+			input:     "a: 1\n...\n%YAML 1.2\n---\nb: 2\n",
+			wantData:  []string{"a: 1\n", "b: 2\n"},
+			wantLines: []int{1, 5},
+		},
+		{
+			name:      "crlf line endings",
+			input:     "a: 1\r\n---\r\nb: 2\r\n",
+			wantData:  []string{"a: 1\n", "b: 2\n"},
+			wantLines: []int{1, 3},
+		},
+		{
+			name:      "content after the separator",
+			input:     "a: 1\n--- {b: 2}\n",
+			wantData:  []string{"a: 1\n", "{b: 2}\n"},
+			wantLines: []int{1, 2},
+		},
+		{
+			name:      "separator like content",
+			input:     "a: |\n  ---x\n----\n",
+			wantData:  []string{"a: |\n  ---x\n----\n"},
+			wantLines: []int{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data []string
+			var lines []int
+			for _, doc := range readAll(t, tt.input) {
+				if len(doc.data) == 0 {
+					continue
+				}
+				data = append(data, string(doc.data))
+				lines = append(lines, doc.line)
+			}
+			if !reflect.DeepEqual(data, tt.wantData) {
+				t.Errorf("documents = %q, want %q", data, tt.wantData)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("lines = %v, want %v", lines, tt.wantLines)
+			}
+		})
+	}
+}
+
+func Test_parseDocument(t *testing.T) {
+	docs := readAll(t, "a: 1\n---\n# comment only\n---\nb: [1\n---\n- 1\n")
+	if len(docs) != 4 {
+		t.Fatalf("got %d documents, want 4", len(docs))
+	}
+	items, _, err := parseDocument("test.yaml", docs[0])
+	if err != nil || !reflect.DeepEqual(items, []pkg.ListItem{{Object: map[string]interface{}{"a": float64(1)}}}) {
+		t.Errorf("parseDocument() = %v, %v", items, err)
+	}
+	if items, _, err := parseDocument("test.yaml", docs[1]); items != nil || err != nil {
+		t.Errorf("parseDocument() = %v, %v, want the comment only document to be skipped", items, err)
+	}
+	_, _, err = parseDocument("test.yaml", docs[2])
+	parseError, ok := err.(*pkg.ParseError)
+	if !ok || parseError.Position.Line != 5 || parseError.Position.Document != 2 {
+		t.Errorf("parseDocument() error = %v, want a parse error on line 5", err)
+	}
+	if _, _, err := parseDocument("test.yaml", docs[3]); err == nil {
+		t.Errorf("parseDocument() want an error for a document which is not an object")
+	}
+}
+
+func Test_parseDocumentJSONArray(t *testing.T) {
+	input := "[\n\t{\"kind\": \"Pod\", \"metadata\": {\"name\": \"a\"}},\n\t{\"kind\": \"List\", \"items\": [{\"kind\": \"Pod\"}]}\n]\n"
+	docs := readAll(t, input)
+	items, sourceMap, err := parseDocument("pods.json", docs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, item := range items {
+		paths = append(paths, strings.Join(item.Path, "/"))
+	}
+	if want := []string{"0", "1/items/0"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("parseDocument() paths = %v, want %v", paths, want)
+	}
+	if got := sourceMap.Item(items[0].Path).Lookup([]string{"metadata", "name"}); got == nil || got.Line != 2 {
+		t.Errorf("Lookup() = %v, want line 2", got)
+	}
+}
+
+func TestValidate_objectErrors(t *testing.T) {
+	conf := testConfig()
+	conf.FileName = "app.yaml"
+	input := "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: web\n---\napiVersion: v1\nmetadata:\n  name: nameless\n---\napiVersion: v1\nkind: Pod\nmetadata:\n  name: web\nspec:\n  containers:\n  - name: web\n    image: nginx\n"
+	results, err := Validate([]byte(input), conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	var parseErrors []pkg.ValidationResult
+	for _, result := range results {
+		if len(result.Kind) == 0 {
+			parseErrors = append(parseErrors, result)
+			continue
+		}
+		kinds = append(kinds, result.Kind)
+	}
+	if want := []string{"Namespace", "Pod"}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("Validate() kinds = %v, want %v", kinds, want)
+	}
+	if len(parseErrors) != 1 {
+		t.Fatalf("Validate() parse errors = %v, want the document without kind", parseErrors)
+	}
+	e := parseErrors[0].ErrorsForOriginal[0]
+	if e.Category != pkg.CategoryParseError || e.Position == nil || e.Position.File != "app.yaml" || e.Position.Document != 1 || e.Position.Line != 6 {
+		t.Errorf("Validate() parse error = %v at %v, want document 1 on line 6 of app.yaml", e, e.Position)
+	}
+}
+
+func TestIsJSONManifest(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "object", input: `{"apiVersion": "v1", "kind": "Pod"}`, want: true},
+		{name: "array", input: `[{"name": "x"}, {"apiVersion": "v1", "kind": "Pod"}]`, want: true},
+		{name: "package.json", input: `{"name": "web", "version": "1.0.0"}`},
+		{name: "array without objects", input: `["a", "b"]`},
+		{name: "kind only", input: `{"kind": "Pod"}`},
+		{name: "invalid json", input: `{"apiVersion": `},
+		{name: "tsconfig.json with comments", input: "{\n  // the target of tsc\n  \"compilerOptions\": {\"target\": \"es2017\"}\n}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsJSONManifest([]byte(tt.input)); got != tt.want {
+				t.Errorf("IsJSONManifest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package kubedd
+
+import (
+	"fmt"
+
+	"github.com/devtron-labs/deprecation-checker/pkg"
+	kLog "github.com/devtron-labs/deprecation-checker/pkg/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ValidateEtcdSnapshot validates the objects stored in the etcd snapshot
+// fileName at the apiVersion they are stored at, which may differ from the
+// one the apiserver serves them at. Objects are reported by their etcd key,
+// those which cannot be decoded, e.g. as they are encrypted at rest, are
+// skipped with a warning
+func ValidateEtcdSnapshot(fileName string, conf *pkg.Config) ([]pkg.ValidationResult, error) {
+	kubeC := newKubeChecker(conf)
+	objects, err := pkg.ReadEtcdSnapshot(fileName)
+	if err != nil {
+		return nil, err
+	}
+	m := &manifests{}
+	var skipped []pkg.EtcdObject
+	for _, object := range objects {
+		if object.Err != nil {
+			skipped = append(skipped, object)
+			continue
+		}
+		if !isSelected(object.Object, conf) {
+			continue
+		}
+		m.objects = append(m.objects, pkg.NormalizeObject(object.Object))
+		m.sources = append(m.sources, pkg.NewSourceMap(object.Key, 0, nil, 0))
+	}
+	if len(skipped) > 0 {
+		kLog.Warn(fmt.Sprintf("skipped %d objects of %s which could not be decoded, e.g. %s: %v", len(skipped), fileName, skipped[0].Key, skipped[0].Err))
+	}
+	return validateManifests(kubeC, m, conf), nil
+}
+
+// isSelected checks whether the kind and namespace of object are selected, as
+// Cluster.FetchK8sObjects does for the objects of a cluster
+func isSelected(object map[string]interface{}, conf *pkg.Config) bool {
+	u := unstructured.Unstructured{Object: object}
+	if pkg.Contains(u.GetKind(), conf.IgnoreKinds) {
+		return false
+	}
+	if len(conf.SelectKinds) > 0 && !pkg.Contains(u.GetKind(), conf.SelectKinds) {
+		return false
+	}
+	namespace := u.GetNamespace()
+	if len(namespace) == 0 {
+		namespace = "default"
+	}
+	if pkg.Contains(namespace, conf.IgnoreNamespaces) {
+		return false
+	}
+	return len(conf.SelectNamespaces) == 0 || pkg.Contains(namespace, conf.SelectNamespaces)
+}
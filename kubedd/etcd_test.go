@@ -0,0 +1,76 @@
+package kubedd
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+// writeEtcdSnapshot writes the json values of keys as revisions of the etcd
+// backend
+func writeEtcdSnapshot(t *testing.T, fileName string, keys [][2]string) {
+	db, err := bolt.Open(fileName, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucket([]byte("key"))
+		if err != nil {
+			return err
+		}
+		for i, kv := range keys {
+			revision := make([]byte, 17)
+			binary.BigEndian.PutUint64(revision, uint64(i+1))
+			revision[8] = '_'
+			value, err := (&mvccpb.KeyValue{Key: []byte(kv[0]), Value: []byte(kv[1])}).Marshal()
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(revision, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateEtcdSnapshot(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "snapshot.db")
+	writeEtcdSnapshot(t, fileName, [][2]string{
+		{"/registry/deployments/apps/web", backupDeployment},
+		{"/registry/namespaces/apps", backupNamespace},
+		{"/registry/pods/kube-system/dns", `{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "dns", "namespace": "kube-system"}}`},
+		{"/registry/secrets/apps/token", "k8s:enc:aescbc:v1:key1:..."},
+	})
+	conf := testConfig()
+	conf.TargetSchemaLocation = "testdata/target-swagger.json"
+	conf.IgnoreNamespaces = []string{"kube-system"}
+	results, err := ValidateEtcdSnapshot(fileName, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("ValidateEtcdSnapshot() = %d results, want 2", len(results))
+	}
+	byKey := map[string]int{}
+	for i, result := range results {
+		byKey[result.FileName] = i
+	}
+	if _, ok := byKey["/registry/secrets/apps/token"]; ok {
+		t.Errorf("ValidateEtcdSnapshot() = %v, want the encrypted secret skipped", results)
+	}
+	deployment := results[byKey["/registry/deployments/apps/web"]]
+	if deployment.APIVersion != "extensions/v1beta1" || !deployment.Deleted {
+		t.Errorf("ValidateEtcdSnapshot() deployment = %s deleted %v, want the removed stored version", deployment.APIVersion, deployment.Deleted)
+	}
+	if _, ok := byKey["/registry/namespaces/apps"]; !ok {
+		t.Errorf("ValidateEtcdSnapshot() = %v, want the apps namespace", results)
+	}
+}
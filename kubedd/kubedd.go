@@ -19,140 +19,139 @@ package kubedd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/devtron-labs/deprecation-checker/pkg"
 	kLog "github.com/devtron-labs/deprecation-checker/pkg/log"
-	"github.com/getkin/kin-openapi/openapi3"
+	"io"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"os"
-	"strings"
 )
 
-var yamlSeparator = []byte("\n---\n")
-
 // Validate a Kubernetes YAML file, parsing out individual resources
 // and validating them all according to the  relevant schemas
 func Validate(input []byte, conf *pkg.Config) ([]pkg.ValidationResult, error) {
-	kubeC := pkg.NewKubeCheckerImpl()
-	if len(conf.TargetSchemaLocation) > 0 {
-		err := kubeC.LoadFromPath(conf.TargetKubernetesVersion, conf.TargetSchemaLocation, false)
-		if err != nil {
-			kLog.Error(err)
-			os.Exit(1)
-		}
-	} else {
-		err := kubeC.LoadFromUrl(conf.TargetKubernetesVersion, false)
-		if err != nil {
-			kLog.Error(err)
-			os.Exit(1)
-		}
+	kubeC := newKubeChecker(conf)
+	if show, ok := parseTerraformShow(input); ok {
+		return validateTerraform(kubeC, show, input, conf), nil
 	}
-	if len(conf.SourceSchemaLocation) > 0 {
-		err := kubeC.LoadFromPath(conf.SourceKubernetesVersion, conf.SourceSchemaLocation, false)
-		if err != nil {
-			kLog.Error(err)
-			os.Exit(1)
-		}
-	} else {
-		err := kubeC.LoadFromUrl(conf.SourceKubernetesVersion, false)
-		if err != nil {
-			kLog.Error(err)
-			os.Exit(1)
-		}
+	manifests, err := parseManifests(conf.FileName, input)
+	if err != nil {
+		return nil, err
 	}
+	return validateManifests(kubeC, manifests, conf), nil
+}
+
+// newKubeChecker loads the schemas of the target and source kubernetes
+// versions, from their schema locations if set
+func newKubeChecker(conf *pkg.Config) pkg.KubeChecker {
+	kubeC := pkg.NewKubeCheckerImpl()
+	loadSchema(kubeC, conf.TargetKubernetesVersion, conf.TargetSchemaLocation)
+	loadSchema(kubeC, conf.SourceKubernetesVersion, conf.SourceSchemaLocation)
 	if len(conf.SourceKubernetesVersion) == 0 && len(conf.TargetKubernetesVersion) != 0 {
 		conf.SourceKubernetesVersion = conf.TargetKubernetesVersion
 	}
-	splits := bytes.Split(input, yamlSeparator)
-	var validationResults []pkg.ValidationResult
-	for _, split := range splits {
-		validationResult, err := kubeC.ValidateYaml(string(split), conf.SourceKubernetesVersion)
-		if err != nil {
-			fmt.Printf("err: %v\n", err)
-			continue
-		}
-		validationResults = append(validationResults, validationResult)
+	return kubeC
+}
+
+func loadSchema(kubeC pkg.KubeChecker, releaseVersion, schemaLocation string) {
+	var err error
+	if len(schemaLocation) > 0 {
+		err = kubeC.LoadFromPath(releaseVersion, schemaLocation, false)
+	} else {
+		err = kubeC.LoadFromUrl(releaseVersion, false)
 	}
-	apiVersionKindCache := make(map[string]bool, 0)
-	for i, result := range validationResults {
-		latestAPIVersion := result.LatestAPIVersion
-		if len(result.LatestAPIVersion) == 0 {
-			latestAPIVersion = result.APIVersion
-		}
-		if _, ok := apiVersionKindCache[fmt.Sprintf("%s/%s", latestAPIVersion, result.Kind)]; !ok {
-			isSupported := kubeC.IsVersionSupported(conf.TargetKubernetesVersion,  latestAPIVersion, result.Kind)
-			apiVersionKindCache[fmt.Sprintf("%s/%s", latestAPIVersion, result.Kind)] = isSupported
+	if err != nil {
+		kLog.Error(err)
+		os.Exit(1)
+	}
+}
 
-		}
-		isSupported := apiVersionKindCache[fmt.Sprintf("%s/%s", latestAPIVersion, result.Kind)]
+// manifests are the resources parsed from one or more sources, with the
+// source maps locating them and the documents which could not be parsed
+type manifests struct {
+	objects     []map[string]interface{}
+	sources     []*pkg.SourceMap
+	parseErrors []pkg.ValidationResult
+}
 
-		if isSupported {
-			result.IsVersionSupported = 1
-		} else {
-			result.IsVersionSupported = 2
-			result.Deleted = true
+// parseManifests parses the yaml or json stream input read from fileName
+func parseManifests(fileName string, input []byte) (*manifests, error) {
+	m := &manifests{}
+	return m, m.add(fileName, input)
+}
+
+func (m *manifests) add(fileName string, input []byte) error {
+	reader := newDocumentReader(bytes.NewReader(input))
+	for {
+		doc, err := reader.Next()
+		if err == io.EOF {
+			return nil
 		}
-		if _, ok := apiVersionKindCache[fmt.Sprintf("%s/%s", result.APIVersion, result.Kind)]; !ok {
-			isSupported := kubeC.IsVersionSupported(conf.TargetKubernetesVersion,  result.APIVersion, result.Kind)
-			apiVersionKindCache[fmt.Sprintf("%s/%s", result.APIVersion, result.Kind)] = isSupported
+		if err != nil {
+			return err
 		}
-		isSupported = apiVersionKindCache[fmt.Sprintf("%s/%s", result.APIVersion, result.Kind)]
-		result.Deleted = !isSupported
-		validationResults[i] = result
-	}
-
-	for i, result := range validationResults {
-		var errorsForLatest []*openapi3.SchemaError
-		var errorsForOriginal []*openapi3.SchemaError
-		for _, schemaError := range result.ErrorsForLatest {
-			if !conf.IgnoreNullErrors || strings.TrimSpace(schemaError.Reason) != "Value is not nullable" {
-				errorsForLatest = append(errorsForLatest, schemaError)
-			}
+		items, sourceMap, err := parseDocument(fileName, doc)
+		if parseError, ok := err.(*pkg.ParseError); ok {
+			m.parseErrors = append(m.parseErrors, parseError.Result())
+			continue
 		}
-		result.ErrorsForLatest = errorsForLatest
-		for _, schemaError := range result.ErrorsForOriginal {
-			if !conf.IgnoreNullErrors  || strings.TrimSpace(schemaError.Reason) != "Value is not nullable" {
-				errorsForOriginal = append(errorsForOriginal, schemaError)
-			}
+		for _, item := range items {
+			m.objects = append(m.objects, pkg.NormalizeObject(item.Object))
+			m.sources = append(m.sources, sourceMap.Item(item.Path))
 		}
-		result.ErrorsForOriginal = errorsForOriginal
-		validationResults[i] = result
 	}
-	return validationResults, nil
 }
 
-func ValidateCluster(cluster *pkg.Cluster, conf *pkg.Config) ([]pkg.ValidationResult, error) {
-	kubeC := pkg.NewKubeCheckerImpl()
-	if len(conf.TargetSchemaLocation) > 0 {
-		err := kubeC.LoadFromPath(conf.TargetKubernetesVersion, conf.TargetSchemaLocation, false)
-		if err != nil {
-			kLog.Error(err)
-			os.Exit(1)
-		}
-	} else {
-		err := kubeC.LoadFromUrl(conf.TargetKubernetesVersion, false)
-		if err != nil {
-			kLog.Error(err)
-			os.Exit(1)
+// validateManifests registers the custom resource definitions of m before
+// validating all of its resources
+func validateManifests(kubeC pkg.KubeChecker, m *manifests, conf *pkg.Config) []pkg.ValidationResult {
+	for _, object := range m.objects {
+		if pkg.IsCustomResourceDefinition(object) {
+			registerCRD(kubeC, object)
 		}
 	}
+	validationResults := validateObjects(kubeC, m.objects, m.sources, conf.SourceKubernetesVersion, conf)
+	return append(m.parseErrors, postProcess(kubeC, validationResults, conf)...)
+}
+
+// ClusterReport is the report of a cluster, the results of its objects along
+// with the field managers which still write through deprecated or removed apis
+// and the deprecated apis the apiserver reports as requested
+type ClusterReport struct {
+	Results       []pkg.ValidationResult
+	ManagerUsages []pkg.ManagerUsage
+	RequestedAPIs []pkg.RequestedAPIUsage
+}
+
+func ValidateCluster(cluster pkg.ClusterSource, conf *pkg.Config) ([]pkg.ValidationResult, error) {
+	report, err := ScanCluster(cluster, conf)
+	if err != nil {
+		return nil, err
+	}
+	return report.Results, nil
+}
+
+// ScanCluster validates the objects of cluster and reports the field managers
+// which last wrote them through apis deprecated or removed in the target
+// version, which the apiVersion of the objects does not show as the
+// apiserver converts them
+func ScanCluster(cluster pkg.ClusterSource, conf *pkg.Config) (*ClusterReport, error) {
+	kubeC := pkg.NewKubeCheckerImpl()
+	loadSchema(kubeC, conf.TargetKubernetesVersion, conf.TargetSchemaLocation)
 	serverVersion, err := cluster.ServerVersion()
 	if err != nil {
 		kLog.Error( err)
 		serverVersion = conf.TargetKubernetesVersion
 	}
-	resources, err := kubeC.GetKinds(serverVersion)
+	resources, err := clusterKinds(kubeC, cluster, serverVersion, conf)
 	if err != nil {
 		kLog.Error(err)
-		//return make([]pkg.ValidationResult, 0), nil
-		resources, err = kubeC.GetKinds(conf.TargetKubernetesVersion)
-		if err != nil {
-			kLog.Error(err)
-			return make([]pkg.ValidationResult, 0), nil
-		}
+		return &ClusterReport{Results: make([]pkg.ValidationResult, 0)}, nil
 	}
-	objects := cluster.FetchK8sObjects(resources, conf)
-	var validationResults []pkg.ValidationResult
-	for _, obj := range objects {
+	var objects []map[string]interface{}
+	objs := cluster.FetchK8sObjects(resources, conf)
+	for _, obj := range objs {
 		annon := obj.GetAnnotations()
 		k8sObj := ""
 		if val, ok := annon["kubectl.kubernetes.io/last-applied-configuration"]; ok {
@@ -164,21 +163,139 @@ func ValidateCluster(cluster *pkg.Cluster, conf *pkg.Config) ([]pkg.ValidationRe
 			}
 			k8sObj = string(bt)
 		}
-		validationResult, err := kubeC.ValidateJson(k8sObj, serverVersion)
-		if err != nil {
+		object := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(k8sObj), &object); err != nil {
 			fmt.Printf("err: %v\n", err)
 			continue
 		}
+		objects = append(objects, pkg.NormalizeObject(object))
+	}
+	validationResults := validateObjects(kubeC, objects, nil, serverVersion, conf)
+	return &ClusterReport{
+		Results:       postProcess(kubeC, validationResults, conf),
+		ManagerUsages: pkg.AnalyzeManagedFields(objs, kubeC, conf.TargetKubernetesVersion),
+		RequestedAPIs: pkg.AnalyzeRequestedAPIs(requestedDeprecatedAPIs(cluster, conf), objs, kubeC, serverVersion, conf.TargetKubernetesVersion),
+	}, nil
+}
+
+// requestedDeprecatedAPIs reads the deprecated apis requested from the
+// apiserver from the metrics file of conf, or else scrapes them from cluster.
+// The section is left out if the metrics are not available, e.g. for
+// snapshots or if /metrics is forbidden
+func requestedDeprecatedAPIs(cluster pkg.ClusterSource, conf *pkg.Config) []pkg.RequestedDeprecatedAPI {
+	if len(conf.MetricsFile) > 0 {
+		apis, err := pkg.ReadRequestedDeprecatedAPIs(conf.MetricsFile)
+		if err != nil {
+			kLog.Error(err)
+		}
+		return apis
+	}
+	source, ok := cluster.(pkg.RequestedAPISource)
+	if !ok {
+		return nil
+	}
+	apis, err := source.FetchRequestedDeprecatedAPIs()
+	if err != nil {
+		kLog.Warn(fmt.Sprintf("metrics of the apiserver are not available: %v", err))
+		return nil
+	}
+	return apis
+}
+
+// clusterKinds returns the kinds of the objects of cluster which are
+// validated, those of the server version and of the CRDs of cluster
+func clusterKinds(kubeC pkg.KubeChecker, cluster pkg.ClusterSource, serverVersion string, conf *pkg.Config) ([]schema.GroupVersionKind, error) {
+	kindsVersion := serverVersion
+	resources, err := kubeC.GetKinds(serverVersion)
+	if err != nil {
+		kLog.Error(err)
+		kindsVersion = conf.TargetKubernetesVersion
+		resources, err = kubeC.GetKinds(conf.TargetKubernetesVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if crds := cluster.FetchCRDs(); len(crds) > 0 {
+		for _, crd := range crds {
+			registerCRD(kubeC, crd.Object)
+		}
+		resources, _ = kubeC.GetKinds(kindsVersion)
+	}
+	return resources, nil
+}
+
+// registerCRD makes the custom resources of crd known to kubeC, so that they
+// are validated against its schemas
+func registerCRD(kubeC pkg.KubeChecker, crd map[string]interface{}) {
+	if err := kubeC.RegisterCRD(crd); err != nil {
+		kLog.Warn(err.Error())
+	}
+}
+
+// validateObjects validates objects against the schemas of releaseVersion, the
+// version they are deployed on, and checks them for the deprecated well-known
+// labels and annotations of the target kubernetes version and for the user
+// defined rules of conf. Rules see all of objects, hence they are parsed
+// before any of them is validated. The findings are located in the source of
+// the objects, if sources are given
+func validateObjects(kubeC pkg.KubeChecker, objects []map[string]interface{}, sources []*pkg.SourceMap, releaseVersion string, conf *pkg.Config) []pkg.ValidationResult {
+	ruleContext := pkg.NewRuleContext(releaseVersion, conf.TargetKubernetesVersion, objects)
+	var validationResults []pkg.ValidationResult
+	for i, object := range objects {
+		validationResult, err := kubeC.ValidateObject(object, releaseVersion)
+		if err != nil {
+			validationResults = append(validationResults, objectError(sourceAt(sources, i), err))
+			continue
+		}
+		deprecations := pkg.CheckWellKnownLabels(object, conf.TargetKubernetesVersion)
+		deprecations = append(deprecations, conf.Rules.Evaluate(object, ruleContext)...)
+		if len(validationResult.LatestAPIVersion) > 0 && validationResult.LatestAPIVersion != validationResult.APIVersion {
+			validationResult.DeprecationForLatest = append(validationResult.DeprecationForLatest, deprecations...)
+		} else {
+			validationResult.DeprecationForOriginal = append(validationResult.DeprecationForOriginal, deprecations...)
+		}
+		if source := sourceAt(sources, i); source != nil {
+			validationResult.FileName = source.File
+			source.LocateResult(&validationResult)
+		}
 		validationResults = append(validationResults, validationResult)
 	}
+	return validationResults
+}
+
+func sourceAt(sources []*pkg.SourceMap, i int) *pkg.SourceMap {
+	if i < len(sources) {
+		return sources[i]
+	}
+	return nil
+}
+
+// objectError reports an object which could not be validated, e.g. as it has
+// no kind, as a parse error located at its document
+func objectError(source *pkg.SourceMap, err error) pkg.ValidationResult {
+	parseError := &pkg.ParseError{Err: err}
+	if position := source.Lookup(nil); position != nil {
+		parseError.Position = *position
+	} else if source != nil {
+		parseError.Position.File = source.File
+	}
+	return parseError.Result()
+}
+
+// postProcess marks the results whose apiVersion is not served by the target
+// kubernetes version as deleted and filters the errors as per conf
+func postProcess(kubeC pkg.KubeChecker, validationResults []pkg.ValidationResult, conf *pkg.Config) []pkg.ValidationResult {
 	apiVersionKindCache := make(map[string]bool, 0)
 	for i, result := range validationResults {
+		if !result.ValidatedAgainstSchema {
+			continue
+		}
 		latestAPIVersion := result.LatestAPIVersion
 		if len(result.LatestAPIVersion) == 0 {
 			latestAPIVersion = result.APIVersion
 		}
 		if _, ok := apiVersionKindCache[fmt.Sprintf("%s/%s", latestAPIVersion, result.Kind)]; !ok {
-			isSupported := kubeC.IsVersionSupported(conf.TargetKubernetesVersion,  latestAPIVersion, result.Kind)
+			isSupported := kubeC.IsVersionSupported(conf.TargetKubernetesVersion, latestAPIVersion, result.Kind)
 			apiVersionKindCache[fmt.Sprintf("%s/%s", latestAPIVersion, result.Kind)] = isSupported
 
 		}
@@ -191,7 +308,7 @@ func ValidateCluster(cluster *pkg.Cluster, conf *pkg.Config) ([]pkg.ValidationRe
 			result.Deleted = true
 		}
 		if _, ok := apiVersionKindCache[fmt.Sprintf("%s/%s", result.APIVersion, result.Kind)]; !ok {
-			isSupported := kubeC.IsVersionSupported(conf.TargetKubernetesVersion,  result.APIVersion, result.Kind)
+			isSupported := kubeC.IsVersionSupported(conf.TargetKubernetesVersion, result.APIVersion, result.Kind)
 			apiVersionKindCache[fmt.Sprintf("%s/%s", result.APIVersion, result.Kind)] = isSupported
 		}
 		isSupported = apiVersionKindCache[fmt.Sprintf("%s/%s", result.APIVersion, result.Kind)]
@@ -200,21 +317,35 @@ func ValidateCluster(cluster *pkg.Cluster, conf *pkg.Config) ([]pkg.ValidationRe
 	}
 
 	for i, result := range validationResults {
-		var errorsForLatest []*openapi3.SchemaError
-		var errorsForOriginal []*openapi3.SchemaError
-		for _, schemaError := range result.ErrorsForLatest {
-			if !conf.IgnoreNullErrors || strings.TrimSpace(schemaError.Reason) != "Value is not nullable" {
-				errorsForLatest = append(errorsForLatest, schemaError)
-			}
+		result.ErrorsForLatest, result.WarningsForLatest = filterErrors(result.ErrorsForLatest, conf)
+		result.ErrorsForOriginal, result.WarningsForOriginal = filterErrors(result.ErrorsForOriginal, conf)
+		result.DeprecationForLatest, _ = filterErrors(result.DeprecationForLatest, conf)
+		result.DeprecationForOriginal, _ = filterErrors(result.DeprecationForOriginal, conf)
+		validationResults[i] = result
+	}
+	return validationResults
+}
+
+// filterErrors drops the errors of the ignored classes, including the null
+// value errors if they are to be ignored, unknown fields are reported as
+// warnings unless validation is strict, the same as kubectl --validate=warn
+func filterErrors(schemaErrors []*pkg.SchemaError, conf *pkg.Config) (errs, warnings []*pkg.SchemaError) {
+	for _, schemaError := range schemaErrors {
+		if conf.IgnoreNullErrors && pkg.IsErrorClass(schemaError, string(pkg.CategoryNullValue)) {
+			continue
 		}
-		result.ErrorsForLatest = errorsForLatest
-		for _, schemaError := range result.ErrorsForOriginal {
-			if !conf.IgnoreNullErrors  || strings.TrimSpace(schemaError.Reason) != "Value is not nullable" {
-				errorsForOriginal = append(errorsForOriginal, schemaError)
-			}
+		if pkg.IsAnyErrorClass(schemaError, conf.IgnoreErrorClasses) {
+			continue
 		}
-		result.ErrorsForOriginal = errorsForOriginal
-		validationResults[i] = result
+		if !conf.Strict && pkg.IsUnknownFieldError(schemaError) {
+			warnings = append(warnings, schemaError)
+			continue
+		}
+		if schemaError.Severity == pkg.SeverityWarning {
+			warnings = append(warnings, schemaError)
+			continue
+		}
+		errs = append(errs, schemaError)
 	}
-	return validationResults, nil
+	return errs, warnings
 }
@@ -44,3 +44,64 @@ func TestValidateCluster(t *testing.T) {
 		})
 	}
 }
+
+func Test_filterErrors(t *testing.T) {
+	unknownField := &pkg.SchemaError{SchemaField: "properties", Reason: `property "containerPort1" is unsupported`}
+	nullValue := &pkg.SchemaError{SchemaField: "nullable", Reason: "Value is not nullable"}
+	typeMismatch := &pkg.SchemaError{SchemaField: "type", Reason: "Field must be set to integer or not be present"}
+	tests := []struct {
+		name         string
+		strict       bool
+		ignoreNull   bool
+		wantErrs     []*pkg.SchemaError
+		wantWarnings []*pkg.SchemaError
+	}{
+		{
+			name:       "strict rejects unknown fields",
+			strict:     true,
+			ignoreNull: true,
+			wantErrs:   []*pkg.SchemaError{unknownField, typeMismatch},
+		},
+		{
+			name:         "unknown fields are warnings when not strict",
+			strict:       false,
+			ignoreNull:   false,
+			wantErrs:     []*pkg.SchemaError{nullValue, typeMismatch},
+			wantWarnings: []*pkg.SchemaError{unknownField},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := pkg.NewDefaultConfig()
+			conf.Strict = tt.strict
+			conf.IgnoreNullErrors = tt.ignoreNull
+			errs, warnings := filterErrors([]*pkg.SchemaError{unknownField, nullValue, typeMismatch}, conf)
+			if !reflect.DeepEqual(errs, tt.wantErrs) {
+				t.Errorf("filterErrors() errs = %v, want %v", errs, tt.wantErrs)
+			}
+			if !reflect.DeepEqual(warnings, tt.wantWarnings) {
+				t.Errorf("filterErrors() warnings = %v, want %v", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func Test_filterErrorsIgnoredClasses(t *testing.T) {
+	unknownField := &pkg.SchemaError{SchemaField: "properties", Reason: `property "containerPort1" is unsupported`}
+	typeMismatch := &pkg.SchemaError{SchemaField: "type", Reason: "Field must be set to integer or not be present"}
+	conf := pkg.NewDefaultConfig()
+	conf.IgnoreErrorClasses = []string{"KDD101"}
+	errs, warnings := filterErrors([]*pkg.SchemaError{unknownField, typeMismatch}, conf)
+	if !reflect.DeepEqual(errs, []*pkg.SchemaError{typeMismatch}) || len(warnings) > 0 {
+		t.Errorf("filterErrors() errs = %v, warnings = %v", errs, warnings)
+	}
+}
+
+func Test_filterErrorsWarnings(t *testing.T) {
+	uncompiledRule := &pkg.SchemaError{SchemaField: "x-kubernetes-validations", Reason: `rule "url(self).getScheme() == 'https'" could not be compiled`, Severity: pkg.SeverityWarning}
+	typeMismatch := &pkg.SchemaError{SchemaField: "type", Reason: "Field must be set to integer or not be present"}
+	errs, warnings := filterErrors([]*pkg.SchemaError{uncompiledRule, typeMismatch}, pkg.NewDefaultConfig())
+	if !reflect.DeepEqual(errs, []*pkg.SchemaError{typeMismatch}) || !reflect.DeepEqual(warnings, []*pkg.SchemaError{uncompiledRule}) {
+		t.Errorf("filterErrors() errs = %v, warnings = %v", errs, warnings)
+	}
+}
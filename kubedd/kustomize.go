@@ -0,0 +1,170 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package kubedd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/devtron-labs/deprecation-checker/pkg"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	kustomizationAPIVersion = "kustomize.config.k8s.io/v1beta1"
+	kustomizationKind       = "Kustomization"
+	transformerConfigKind   = "TransformerConfig"
+)
+
+// ValidateKustomization builds the kustomization in dir as kustomize build
+// does and validates the resulting resources. The kustomization, along with
+// its local bases and transformer configurations, is checked for selectors of
+// apis which are removed in the target kubernetes version
+func ValidateKustomization(dir string, conf *pkg.Config) ([]pkg.ValidationResult, error) {
+	kubeC := newKubeChecker(conf)
+	results, err := checkKustomization(kubeC, dir, conf, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	resMap, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(filesys.MakeFsOnDisk(), dir)
+	if err != nil {
+		return nil, err
+	}
+	output, err := resMap.AsYaml()
+	if err != nil {
+		return nil, err
+	}
+	m, err := parseManifests(dir, output)
+	if err != nil {
+		return nil, err
+	}
+	return append(results, validateManifests(kubeC, m, conf)...), nil
+}
+
+// checkKustomization checks the selectors of the kustomization in dir and of
+// the kustomizations of its local resources, visited keeps the directories
+// which are already checked
+func checkKustomization(kubeC pkg.KubeChecker, dir string, conf *pkg.Config, visited map[string]bool) ([]pkg.ValidationResult, error) {
+	if abs, err := filepath.Abs(dir); err == nil {
+		if visited[abs] {
+			return nil, nil
+		}
+		visited[abs] = true
+	}
+	fileName := kustomizationFile(dir)
+	if len(fileName) == 0 {
+		return nil, nil
+	}
+	kustomization, result, err := checkSelectorsOfFile(kubeC, fileName, pkg.KustomizationSelectorPaths, conf)
+	if err != nil {
+		return nil, err
+	}
+	var results []pkg.ValidationResult
+	if result != nil {
+		setKind(result, kustomization, kustomizationAPIVersion, kustomizationKind)
+		results = append(results, *result)
+	}
+	for _, configuration := range stringList(kustomization["configurations"]) {
+		_, result, err := checkSelectorsOfFile(kubeC, filepath.Join(dir, configuration), pkg.TransformerConfigSelectorPaths, conf)
+		if err != nil {
+			return nil, err
+		}
+		if result != nil {
+			result.Kind = transformerConfigKind
+			results = append(results, *result)
+		}
+	}
+	for _, field := range []string{"resources", "bases", "components"} {
+		for _, resource := range stringList(kustomization[field]) {
+			path := filepath.Join(dir, resource)
+			if info, err := os.Stat(path); err != nil || !info.IsDir() {
+				continue
+			}
+			baseResults, err := checkKustomization(kubeC, path, conf, visited)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, baseResults...)
+		}
+	}
+	return results, nil
+}
+
+// checkSelectorsOfFile checks the selectors at paths of the yaml file
+// fileName. A result is returned if any of them selects a removed api
+func checkSelectorsOfFile(kubeC pkg.KubeChecker, fileName string, paths []string, conf *pkg.Config) (map[string]interface{}, *pkg.ValidationResult, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	document := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, nil, err
+	}
+	errs := pkg.CheckSelectors(document, paths, kubeC, conf.SourceKubernetesVersion, conf.TargetKubernetesVersion)
+	errs, _ = filterErrors(errs, conf)
+	if len(errs) == 0 {
+		return document, nil, nil
+	}
+	result := &pkg.ValidationResult{
+		FileName:               fileName,
+		ResourceName:           fileName,
+		ValidatedAgainstSchema: true,
+		DeprecationForOriginal: errs,
+	}
+	if sourceMap, err := pkg.ParseSourceMap(fileName, 0, data, 0); err == nil {
+		sourceMap.LocateResult(result)
+	}
+	return document, result, nil
+}
+
+// kustomizationFile returns the path of the kustomization file in dir, if any
+func kustomizationFile(dir string) string {
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+func setKind(result *pkg.ValidationResult, document map[string]interface{}, apiVersion, kind string) {
+	result.APIVersion, result.Kind = apiVersion, kind
+	if v, ok := document["apiVersion"].(string); ok && len(v) > 0 {
+		result.APIVersion = v
+	}
+	if k, ok := document["kind"].(string); ok && len(k) > 0 {
+		result.Kind = k
+	}
+}
+
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	var out []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
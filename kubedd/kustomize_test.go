@@ -0,0 +1,73 @@
+package kubedd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/devtron-labs/deprecation-checker/pkg"
+)
+
+func TestValidateKustomization(t *testing.T) {
+	results, err := ValidateKustomization("testdata/kustomize/overlay", testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the selectors select apis served by the target, only the deployment of
+	// the base is reported
+	if len(results) != 1 {
+		t.Fatalf("ValidateKustomization() = %d results, want 1", len(results))
+	}
+	result := results[0]
+	if result.Kind != "Deployment" || result.ResourceName != "prod-web" {
+		t.Errorf("ValidateKustomization() = %s %s, want Deployment prod-web", result.Kind, result.ResourceName)
+	}
+	if result.FileName != "testdata/kustomize/overlay" {
+		t.Errorf("ValidateKustomization() file = %s, want testdata/kustomize/overlay", result.FileName)
+	}
+	if len(result.ErrorsForOriginal) != 1 || !pkg.IsErrorClass(result.ErrorsForOriginal[0], "type-mismatch") {
+		t.Errorf("ValidateKustomization() errors = %v, want the type mismatch of the patched port", result.ErrorsForOriginal)
+	}
+}
+
+func Test_checkKustomization(t *testing.T) {
+	conf := testConfig()
+	results, err := checkKustomization(newKubeChecker(conf), "testdata/kustomize/overlay", conf, map[string]bool{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("checkKustomization() = %v, want no stale selectors", results)
+	}
+	if _, err := checkKustomization(newKubeChecker(conf), "testdata/kustomize/missing", conf, map[string]bool{}); err != nil {
+		t.Errorf("checkKustomization() of a directory without kustomization = %v", err)
+	}
+}
+
+func TestValidateKustomization_staleSelectors(t *testing.T) {
+	// the source version defaults to the target version, as it does for the
+	// kustomize command
+	conf := testConfig()
+	conf.TargetSchemaLocation = "testdata/target-swagger.json"
+	conf.SourceSchemaLocation = "testdata/target-swagger.json"
+	conf.SourceKubernetesVersion = ""
+	results, err := ValidateKustomization("testdata/kustomize/overlay", conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, result := range results {
+		for _, e := range result.DeprecationForOriginal {
+			if pkg.IsErrorClass(e, "KDD203") {
+				got[result.Kind] = strings.Join(e.JSONPointer(), "/")
+			}
+		}
+	}
+	want := map[string]string{
+		kustomizationKind:     "patches/0/target/version",
+		transformerConfigKind: "images/0/version",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateKustomization() stale selectors = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package kubedd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/devtron-labs/deprecation-checker/pkg"
+	kLog "github.com/devtron-labs/deprecation-checker/pkg/log"
+)
+
+// ReleaseResult are the results of the manifest stored for a helm release
+type ReleaseResult struct {
+	Release *pkg.HelmRelease
+	Results []pkg.ValidationResult
+}
+
+// ValidateHelmReleases validates the manifests helm stored for the latest
+// revision of every release of cluster. Helm builds the objects of these
+// manifests on the next upgrade, which fails if their apiVersions are removed
+func ValidateHelmReleases(cluster *pkg.Cluster, conf *pkg.Config) ([]ReleaseResult, error) {
+	kubeC := pkg.NewKubeCheckerImpl()
+	loadSchema(kubeC, conf.TargetKubernetesVersion, conf.TargetSchemaLocation)
+	serverVersion, err := cluster.ServerVersion()
+	if err != nil {
+		kLog.Error(err)
+		serverVersion = conf.TargetKubernetesVersion
+	}
+	if len(conf.SourceSchemaLocation) > 0 {
+		loadSchema(kubeC, serverVersion, conf.SourceSchemaLocation)
+	}
+	for _, crd := range cluster.FetchCRDs() {
+		registerCRD(kubeC, crd.Object)
+	}
+	releases, err := cluster.FetchHelmReleases(conf)
+	if err != nil {
+		return nil, err
+	}
+	releaseConf := *conf
+	releaseConf.SourceKubernetesVersion = serverVersion
+	var releaseResults []ReleaseResult
+	for _, rls := range releases {
+		fileName := fmt.Sprintf("%s/%s", rls.Namespace, rls.Name)
+		m, err := parseManifests(fileName, []byte(rls.Manifest))
+		if err != nil {
+			return nil, err
+		}
+		releaseResults = append(releaseResults, ReleaseResult{
+			Release: rls,
+			Results: validateManifests(kubeC, m, &releaseConf),
+		})
+	}
+	return releaseResults, nil
+}
+
+// FixHelmRelease rewrites the deprecated and removed apiVersions of the
+// manifest stored for the release to their latest versions and updates the
+// release secret, which is backed up to backupDir first. The rewritten
+// apiVersions and the path of the backup are returned
+func FixHelmRelease(cluster *pkg.Cluster, result ReleaseResult, backupDir string) ([]string, string, error) {
+	manifest, fixes := rewriteManifest(result.Release.Manifest, apiVersionReplacements(result.Results))
+	if len(fixes) == 0 {
+		return nil, "", nil
+	}
+	original := result.Release.Manifest
+	result.Release.Manifest = manifest
+	backup, err := cluster.UpdateHelmRelease(result.Release, backupDir)
+	if err != nil {
+		result.Release.Manifest = original
+		return nil, backup, err
+	}
+	return fixes, backup, nil
+}
+
+// apiVersionReplacements maps the apiVersion and kind of the deprecated and
+// removed resources of results to their latest apiVersion
+func apiVersionReplacements(results []pkg.ValidationResult) map[string]string {
+	replacements := map[string]string{}
+	for _, result := range results {
+		if !result.Deleted && !result.Deprecated {
+			continue
+		}
+		if len(result.LatestAPIVersion) == 0 || result.LatestAPIVersion == result.APIVersion {
+			continue
+		}
+		replacements[result.VersionKind()] = result.LatestAPIVersion
+	}
+	return replacements
+}
+
+var (
+	manifestSeparator = regexp.MustCompile(`(?m)^---.*$`)
+	manifestKind      = regexp.MustCompile(`(?m)^kind:[ \t]*["']?([^"'\s]+)["']?[ \t]*$`)
+	manifestVersion   = regexp.MustCompile(`(?m)^apiVersion:[ \t]*["']?([^"'\s]+)["']?[ \t]*$`)
+)
+
+// rewriteManifest replaces the top level apiVersion of the documents of
+// manifest whose apiVersion and kind are replaced, leaving the rest of the
+// manifest as it is. The replaced apiVersions are returned as well
+func rewriteManifest(manifest string, replacements map[string]string) (string, []string) {
+	if len(replacements) == 0 {
+		return manifest, nil
+	}
+	var out strings.Builder
+	var fixes []string
+	seen := map[string]bool{}
+	start := 0
+	bounds := append(manifestSeparator.FindAllStringIndex(manifest, -1), []int{len(manifest), len(manifest)})
+	for _, bound := range bounds {
+		doc := manifest[start:bound[0]]
+		kind := manifestKind.FindStringSubmatch(doc)
+		version := manifestVersion.FindStringSubmatchIndex(doc)
+		if kind != nil && version != nil {
+			apiVersion := doc[version[2]:version[3]]
+			key := fmt.Sprintf("%s/%s", apiVersion, kind[1])
+			if latest, ok := replacements[key]; ok {
+				doc = doc[:version[2]] + latest + doc[version[3]:]
+				fix := fmt.Sprintf("%s %s -> %s", kind[1], apiVersion, latest)
+				if !seen[fix] {
+					seen[fix] = true
+					fixes = append(fixes, fix)
+				}
+			}
+		}
+		out.WriteString(doc)
+		out.WriteString(manifest[bound[0]:bound[1]])
+		start = bound[1]
+	}
+	return out.String(), fixes
+}
@@ -0,0 +1,81 @@
+package kubedd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/devtron-labs/deprecation-checker/pkg"
+)
+
+const releaseManifest = `---
+# Source: web/templates/deployment.yaml
+apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: web
+---
+# Source: web/templates/ingress.yaml
+apiVersion: "extensions/v1beta1"
+kind: Ingress
+metadata:
+  name: web
+---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`
+
+func Test_apiVersionReplacements(t *testing.T) {
+	results := []pkg.ValidationResult{
+		{APIVersion: "extensions/v1beta1", Kind: "Deployment", LatestAPIVersion: "apps/v1", Deleted: true},
+		{APIVersion: "extensions/v1beta1", Kind: "Ingress", LatestAPIVersion: "networking.k8s.io/v1", Deprecated: true},
+		{APIVersion: "autoscaling/v1", Kind: "HorizontalPodAutoscaler", LatestAPIVersion: "autoscaling/v2beta2"},
+		{APIVersion: "v1", Kind: "Service"},
+	}
+	want := map[string]string{
+		"extensions/v1beta1/Deployment": "apps/v1",
+		"extensions/v1beta1/Ingress":    "networking.k8s.io/v1",
+	}
+	if got := apiVersionReplacements(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("apiVersionReplacements() = %v, want %v", got, want)
+	}
+}
+
+func Test_rewriteManifest(t *testing.T) {
+	replacements := map[string]string{
+		"extensions/v1beta1/Deployment": "apps/v1",
+		"extensions/v1beta1/Ingress":    "networking.k8s.io/v1",
+	}
+	got, fixes := rewriteManifest(releaseManifest, replacements)
+	want := `---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+# Source: web/templates/ingress.yaml
+apiVersion: "networking.k8s.io/v1"
+kind: Ingress
+metadata:
+  name: web
+---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`
+	if got != want {
+		t.Errorf("rewriteManifest() = %s, want %s", got, want)
+	}
+	wantFixes := []string{"Deployment extensions/v1beta1 -> apps/v1", "Ingress extensions/v1beta1 -> networking.k8s.io/v1"}
+	if !reflect.DeepEqual(fixes, wantFixes) {
+		t.Errorf("rewriteManifest() fixes = %v, want %v", fixes, wantFixes)
+	}
+	if unchanged, fixes := rewriteManifest(releaseManifest, nil); unchanged != releaseManifest || fixes != nil {
+		t.Errorf("rewriteManifest() without replacements = %s, %v", unchanged, fixes)
+	}
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package kubedd
+
+import (
+	"github.com/devtron-labs/deprecation-checker/pkg"
+)
+
+// SnapshotCluster captures the objects of cluster ValidateCluster validates,
+// of the kinds served by the server version and the CRDs of cluster, so that
+// it is validated offline against any target version later
+func SnapshotCluster(cluster *pkg.Cluster, conf *pkg.Config) (*pkg.ClusterSnapshot, error) {
+	kubeC := pkg.NewKubeCheckerImpl()
+	serverVersion, err := cluster.ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+	if len(conf.SourceSchemaLocation) > 0 {
+		loadSchema(kubeC, serverVersion, conf.SourceSchemaLocation)
+	}
+	kinds, err := clusterKinds(kubeC, cluster, serverVersion, conf)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.Snapshot(kinds)
+}
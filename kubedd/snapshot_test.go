@@ -0,0 +1,47 @@
+package kubedd
+
+import (
+	"testing"
+
+	"github.com/devtron-labs/deprecation-checker/pkg"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+func TestValidateCluster_snapshot(t *testing.T) {
+	deployment := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "apps"},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "web"}},
+				"spec": map[string]interface{}{"containers": []interface{}{
+					map[string]interface{}{"name": "web", "image": "nginx", "ports": []interface{}{map[string]interface{}{"containerPort": "http"}}},
+				}},
+			},
+		},
+	}}
+	snapshot := &pkg.ClusterSnapshot{
+		Version: &version.Info{Major: "1", Minor: "22"},
+		Resources: []*v1.APIResourceList{
+			{GroupVersion: "apps/v1", APIResources: []v1.APIResource{{Name: "deployments", Kind: "Deployment", Namespaced: true}}},
+		},
+		Objects: map[schema.GroupVersionResource][]unstructured.Unstructured{
+			{Group: "apps", Version: "v1", Resource: "deployments"}: {deployment},
+		},
+	}
+	results, err := ValidateCluster(snapshot, testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].ResourceName != "web" {
+		t.Fatalf("ValidateCluster() = %v, want the snapshotted deployment", results)
+	}
+	if errs := results[0].ErrorsForOriginal; len(errs) != 1 || !pkg.IsErrorClass(errs[0], "type-mismatch") {
+		t.Errorf("ValidateCluster() errors = %v, want the type mismatch of the port", errs)
+	}
+}
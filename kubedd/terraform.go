@@ -0,0 +1,264 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package kubedd
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/devtron-labs/deprecation-checker/pkg"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// terraformShow is the output of terraform show -json, for a plan the
+// planned values are set and for a state its values
+type terraformShow struct {
+	FormatVersion string           `json:"format_version"`
+	Values        *terraformValues `json:"values"`
+	PlannedValues *terraformValues `json:"planned_values"`
+}
+
+type terraformValues struct {
+	RootModule *terraformModule `json:"root_module"`
+}
+
+type terraformModule struct {
+	Resources    []terraformResource `json:"resources"`
+	ChildModules []terraformModule   `json:"child_modules"`
+}
+
+type terraformResource struct {
+	Address string                 `json:"address"`
+	Mode    string                 `json:"mode"`
+	Type    string                 `json:"type"`
+	Values  map[string]interface{} `json:"values"`
+}
+
+const (
+	terraformManifestType    = "kubernetes_manifest"
+	terraformHelmReleaseType = "helm_release"
+)
+
+// terraformResourceKinds are the kinds managed by the typed resources of the
+// terraform kubernetes provider, keyed by resource type
+var terraformResourceKinds = map[string]schema.GroupVersionKind{
+	"kubernetes_api_service":                       {Group: "apiregistration.k8s.io", Version: "v1", Kind: "APIService"},
+	"kubernetes_certificate_signing_request":       {Group: "certificates.k8s.io", Version: "v1beta1", Kind: "CertificateSigningRequest"},
+	"kubernetes_certificate_signing_request_v1":    {Group: "certificates.k8s.io", Version: "v1", Kind: "CertificateSigningRequest"},
+	"kubernetes_cluster_role":                      {Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+	"kubernetes_cluster_role_binding":              {Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"},
+	"kubernetes_config_map":                        {Version: "v1", Kind: "ConfigMap"},
+	"kubernetes_cron_job":                          {Group: "batch", Version: "v1beta1", Kind: "CronJob"},
+	"kubernetes_cron_job_v1":                       {Group: "batch", Version: "v1", Kind: "CronJob"},
+	"kubernetes_csi_driver":                        {Group: "storage.k8s.io", Version: "v1beta1", Kind: "CSIDriver"},
+	"kubernetes_csi_driver_v1":                     {Group: "storage.k8s.io", Version: "v1", Kind: "CSIDriver"},
+	"kubernetes_daemonset":                         {Group: "apps", Version: "v1", Kind: "DaemonSet"},
+	"kubernetes_deployment":                        {Group: "apps", Version: "v1", Kind: "Deployment"},
+	"kubernetes_endpoints":                         {Version: "v1", Kind: "Endpoints"},
+	"kubernetes_horizontal_pod_autoscaler":         {Group: "autoscaling", Version: "v1", Kind: "HorizontalPodAutoscaler"},
+	"kubernetes_horizontal_pod_autoscaler_v2beta2": {Group: "autoscaling", Version: "v2beta2", Kind: "HorizontalPodAutoscaler"},
+	"kubernetes_ingress":                           {Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"},
+	"kubernetes_ingress_v1":                        {Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+	"kubernetes_ingress_class":                     {Group: "networking.k8s.io", Version: "v1", Kind: "IngressClass"},
+	"kubernetes_job":                               {Group: "batch", Version: "v1", Kind: "Job"},
+	"kubernetes_limit_range":                       {Version: "v1", Kind: "LimitRange"},
+	"kubernetes_mutating_webhook_configuration":    {Group: "admissionregistration.k8s.io", Version: "v1", Kind: "MutatingWebhookConfiguration"},
+	"kubernetes_namespace":                         {Version: "v1", Kind: "Namespace"},
+	"kubernetes_network_policy":                    {Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"},
+	"kubernetes_persistent_volume":                 {Version: "v1", Kind: "PersistentVolume"},
+	"kubernetes_persistent_volume_claim":           {Version: "v1", Kind: "PersistentVolumeClaim"},
+	"kubernetes_pod":                               {Version: "v1", Kind: "Pod"},
+	"kubernetes_pod_disruption_budget":             {Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget"},
+	"kubernetes_pod_security_policy":               {Group: "policy", Version: "v1beta1", Kind: "PodSecurityPolicy"},
+	"kubernetes_priority_class":                    {Group: "scheduling.k8s.io", Version: "v1", Kind: "PriorityClass"},
+	"kubernetes_replication_controller":            {Version: "v1", Kind: "ReplicationController"},
+	"kubernetes_resource_quota":                    {Version: "v1", Kind: "ResourceQuota"},
+	"kubernetes_role":                              {Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"},
+	"kubernetes_role_binding":                      {Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"},
+	"kubernetes_secret":                            {Version: "v1", Kind: "Secret"},
+	"kubernetes_service":                           {Version: "v1", Kind: "Service"},
+	"kubernetes_service_account":                   {Version: "v1", Kind: "ServiceAccount"},
+	"kubernetes_stateful_set":                      {Group: "apps", Version: "v1", Kind: "StatefulSet"},
+	"kubernetes_storage_class":                     {Group: "storage.k8s.io", Version: "v1", Kind: "StorageClass"},
+	"kubernetes_validating_webhook_configuration":  {Group: "admissionregistration.k8s.io", Version: "v1", Kind: "ValidatingWebhookConfiguration"},
+}
+
+// parseTerraformShow decodes input if it is the json output of terraform show
+// for a plan or a state
+func parseTerraformShow(input []byte) (*terraformShow, bool) {
+	if !bytes.HasPrefix(bytes.TrimSpace(input), []byte("{")) {
+		return nil, false
+	}
+	var show terraformShow
+	if err := json.Unmarshal(input, &show); err != nil || len(show.FormatVersion) == 0 {
+		return nil, false
+	}
+	if show.Values == nil && show.PlannedValues == nil {
+		return nil, false
+	}
+	return &show, true
+}
+
+// terraformObjects are the kubernetes objects of the resources of a plan or
+// state, with the resources declaring them keyed by the path of the objects
+// within the plan, which their results are located at
+type terraformObjects struct {
+	manifests
+	resources map[string]terraformObject
+}
+
+// terraformObject is the resource declaring an object of a plan or state
+type terraformObject struct {
+	address string
+	// typed is set for the objects built from the typed resources, which only
+	// declare the apiVersion and kind of the object rather than its manifest
+	typed bool
+}
+
+// validateTerraform validates the kubernetes objects managed by the planned
+// or current resources of show. The manifests of the kubernetes_manifest and
+// helm_release resources are validated in full, for the typed resources only
+// their apiVersion is checked. Findings are attributed to the resource address
+func validateTerraform(kubeC pkg.KubeChecker, show *terraformShow, input []byte, conf *pkg.Config) []pkg.ValidationResult {
+	values, root := show.PlannedValues, "planned_values"
+	if values == nil {
+		values, root = show.Values, "values"
+	}
+	sourceMap, _ := pkg.ParseSourceMap(conf.FileName, 0, input, 0)
+	objects := &terraformObjects{resources: map[string]terraformObject{}}
+	if values.RootModule != nil {
+		objects.addModule(*values.RootModule, []string{root, "root_module"}, sourceMap)
+	}
+	results := validateManifests(kubeC, &objects.manifests, conf)
+	for i := range results {
+		if results[i].Position == nil {
+			continue
+		}
+		object, ok := objects.resources[results[i].Position.Item]
+		if !ok {
+			continue
+		}
+		results[i].Address = object.address
+		if object.typed {
+			results[i].ErrorsForOriginal, results[i].ErrorsForLatest = nil, nil
+			results[i].WarningsForOriginal, results[i].WarningsForLatest = nil, nil
+		}
+	}
+	return results
+}
+
+func (t *terraformObjects) addModule(module terraformModule, path []string, sourceMap *pkg.SourceMap) {
+	for i, resource := range module.Resources {
+		if resource.Mode != "managed" {
+			continue
+		}
+		t.addResource(resource, append(append([]string(nil), path...), "resources", strconv.Itoa(i), "values"), sourceMap)
+	}
+	for i, child := range module.ChildModules {
+		t.addModule(child, append(append([]string(nil), path...), "child_modules", strconv.Itoa(i)), sourceMap)
+	}
+}
+
+func (t *terraformObjects) addResource(resource terraformResource, path []string, sourceMap *pkg.SourceMap) {
+	switch resource.Type {
+	case terraformManifestType:
+		if object, ok := resource.Values["manifest"].(map[string]interface{}); ok {
+			t.add(resource.Address, object, sourceMap.Item(append(path, "manifest")), false)
+		}
+	case terraformHelmReleaseType:
+		manifest, ok := resource.Values["manifest"].(string)
+		if !ok || len(manifest) == 0 {
+			return
+		}
+		// the findings are located at the manifest attribute, as the manifest
+		// is a string within the plan
+		manifestSource := sourceMap.Item(append(path, "manifest"))
+		for _, object := range helmReleaseObjects(manifest) {
+			t.add(resource.Address, object, manifestSource, false)
+		}
+	default:
+		gvk, ok := terraformResourceKinds[resource.Type]
+		if !ok {
+			return
+		}
+		object := map[string]interface{}{
+			"apiVersion": gvk.GroupVersion().String(),
+			"kind":       gvk.Kind,
+			"metadata":   terraformMetadata(resource.Values),
+		}
+		t.add(resource.Address, object, sourceMap.Item(path), true)
+	}
+}
+
+func (t *terraformObjects) add(address string, object map[string]interface{}, source *pkg.SourceMap, typed bool) {
+	for _, item := range pkg.ExpandList(object) {
+		itemSource := source.Item(item.Path)
+		t.objects = append(t.objects, pkg.NormalizeObject(item.Object))
+		t.sources = append(t.sources, itemSource)
+		if position := itemSource.Lookup(nil); position != nil {
+			t.resources[position.Item] = terraformObject{address: address, typed: typed}
+		}
+	}
+}
+
+// helmReleaseObjects decodes the manifest of a helm release, which the helm
+// provider stores as the json of the rendered objects keyed by their ids
+func helmReleaseObjects(manifest string) []map[string]interface{} {
+	decoded := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(manifest), &decoded); err != nil {
+		return nil
+	}
+	if _, ok := decoded["kind"]; ok {
+		return []map[string]interface{}{decoded}
+	}
+	var objects []map[string]interface{}
+	for _, key := range sortedKeys(decoded) {
+		if object, ok := decoded[key].(map[string]interface{}); ok {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// terraformMetadata returns the name and namespace of a typed resource, whose
+// metadata is a block list
+func terraformMetadata(values map[string]interface{}) map[string]interface{} {
+	metadata := map[string]interface{}{}
+	blocks, _ := values["metadata"].([]interface{})
+	if len(blocks) == 0 {
+		return metadata
+	}
+	block, _ := blocks[0].(map[string]interface{})
+	for _, field := range []string{"name", "namespace"} {
+		if value, ok := block[field].(string); ok && len(value) > 0 {
+			metadata[field] = value
+		}
+	}
+	return metadata
+}
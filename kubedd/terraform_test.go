@@ -0,0 +1,130 @@
+package kubedd
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/devtron-labs/deprecation-checker/pkg"
+)
+
+func Test_parseTerraformShow(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{"plan", `{"format_version": "0.2", "planned_values": {"root_module": {}}}`, true},
+		{"state", `{"format_version": "0.2", "values": {"root_module": {}}}`, true},
+		{"json manifest", `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "apps"}}`, false},
+		{"empty state", `{"format_version": "0.2"}`, false},
+		{"yaml manifest", "apiVersion: v1\nkind: Namespace\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := parseTerraformShow([]byte(tt.input)); got != tt.want {
+				t.Errorf("parseTerraformShow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTerraform(t *testing.T) {
+	conf := testConfig()
+	conf.FileName = "testdata/terraform-plan.json"
+	input, err := ioutil.ReadFile(conf.FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := Validate(input, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byAddress := map[string]pkg.ValidationResult{}
+	for _, result := range results {
+		byAddress[result.Address] = result
+	}
+	if len(results) != 3 || len(byAddress) != 3 {
+		t.Fatalf("Validate() = %v, want a result per managed kubernetes resource", results)
+	}
+	manifest := byAddress["kubernetes_manifest.web"]
+	if manifest.Kind != "Deployment" || manifest.FileName != conf.FileName {
+		t.Errorf("Validate() kubernetes_manifest = %s in %s", manifest.Kind, manifest.FileName)
+	}
+	if len(manifest.ErrorsForOriginal) != 1 || !pkg.IsErrorClass(manifest.ErrorsForOriginal[0], "type-mismatch") {
+		t.Errorf("Validate() kubernetes_manifest errors = %v, want the type mismatch of the port", manifest.ErrorsForOriginal)
+	}
+	if position := manifest.ErrorsForOriginal[0].Position; position == nil || position.Line != 21 {
+		t.Errorf("Validate() kubernetes_manifest error position = %v, want line 21", position)
+	}
+	namespace := byAddress["module.platform.kubernetes_namespace.apps"]
+	if namespace.Kind != "Namespace" || namespace.ResourceName != "apps" || len(namespace.ErrorsForOriginal) != 0 {
+		t.Errorf("Validate() kubernetes_namespace = %s %s %v", namespace.Kind, namespace.ResourceName, namespace.ErrorsForOriginal)
+	}
+	release := byAddress["module.platform.helm_release.legacy"]
+	if release.APIVersion != "extensions/v1beta1" || release.LatestAPIVersion != "apps/v1" || release.ResourceName != "legacy" {
+		t.Errorf("Validate() helm_release = %s %s -> %s", release.ResourceName, release.APIVersion, release.LatestAPIVersion)
+	}
+}
+
+const brokenTerraformPlan = `{
+  "format_version": "0.2",
+  "planned_values": {
+    "root_module": {
+      "resources": [
+        {
+          "address": "kubernetes_manifest.broken",
+          "mode": "managed",
+          "type": "kubernetes_manifest",
+          "values": {"manifest": {"apiVersion": "v1", "kind": "ConfigMap"}}
+        },
+        {
+          "address": "kubernetes_manifest.web",
+          "mode": "managed",
+          "type": "kubernetes_manifest",
+          "values": {
+            "manifest": {
+              "apiVersion": "apps/v1",
+              "kind": "Deployment",
+              "metadata": {"name": "web"},
+              "spec": {"replicas": "two"}
+            }
+          }
+        },
+        {
+          "address": "kubernetes_service.web",
+          "mode": "managed",
+          "type": "kubernetes_service",
+          "values": {"metadata": [{"name": "web"}]}
+        }
+      ]
+    }
+  }
+}`
+
+func TestValidateTerraform_invalidResource(t *testing.T) {
+	conf := testConfig()
+	conf.FileName = "plan.json"
+	results, err := Validate([]byte(brokenTerraformPlan), conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byAddress := map[string]pkg.ValidationResult{}
+	for _, result := range results {
+		byAddress[result.Address] = result
+	}
+	if len(results) != 3 || len(byAddress) != 3 {
+		t.Fatalf("Validate() = %v, want a result per resource", results)
+	}
+	broken := byAddress["kubernetes_manifest.broken"]
+	if len(broken.Kind) != 0 || len(broken.ErrorsForOriginal) != 1 || !pkg.IsErrorClass(broken.ErrorsForOriginal[0], "parse-error") {
+		t.Errorf("Validate() kubernetes_manifest.broken = %s %v, want a parse error", broken.Kind, broken.ErrorsForOriginal)
+	}
+	web := byAddress["kubernetes_manifest.web"]
+	if web.Kind != "Deployment" || len(web.ErrorsForOriginal) == 0 {
+		t.Errorf("Validate() kubernetes_manifest.web = %s %v, want the errors of the deployment", web.Kind, web.ErrorsForOriginal)
+	}
+	service := byAddress["kubernetes_service.web"]
+	if service.Kind != "Service" || len(service.ErrorsForOriginal) != 0 {
+		t.Errorf("Validate() kubernetes_service.web = %s %v, want the typed service without errors", service.Kind, service.ErrorsForOriginal)
+	}
+}
@@ -18,9 +18,7 @@
 package kubedd
 
 import (
-	"bytes"
 	"fmt"
-	"runtime"
 	"strings"
 )
 
@@ -80,15 +78,6 @@ func getString(body map[string]interface{}, key string) (string, error) {
 	return typedValue, nil
 }
 
-// detectLineBreak returns the relevant platform specific line ending
-func detectLineBreak(haystack []byte) string {
-	windowsLineEnding := bytes.Contains(haystack, []byte("\r\n"))
-	if windowsLineEnding && runtime.GOOS == "windows" {
-		return "\r\n"
-	}
-	return "\n"
-}
-
 // in is a method which tests whether the `key` is in the set
 func in(set []string, key string) bool {
 	for _, k := range set {
@@ -18,18 +18,19 @@
 package main
 
 import (
+	"bytes"
 	"crypto/tls"
 	"fmt"
 	"github.com/devtron-labs/deprecation-checker/kubedd"
 	"github.com/devtron-labs/deprecation-checker/pkg"
 	log2 "github.com/devtron-labs/deprecation-checker/pkg/log"
-	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/prometheus/common/log"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/fatih/color"
@@ -57,8 +58,27 @@ var (
 	forceColor bool
 
 	config = pkg.NewDefaultConfig()
+
+	// stdinContents are the manifests read from stdin, which can be read once
+	stdinContents []byte
+
+	chartOptions = kubedd.ChartOptions{}
+
+	// fixReleases tells kubedd to rewrite the deprecated apiVersions of the
+	// manifests stored for helm releases, backing them up to releasesBackupDir
+	fixReleases       bool
+	releasesBackupDir = ""
+	// clusterSnapshot is the cluster snapshot validated instead of a live
+	// cluster, snapshotOutput the file a snapshot is written to
+	clusterSnapshot = ""
+	snapshotOutput  = ""
 )
 
+// stdinFileName is the file name argument for the manifests piped to kubedd
+const stdinFileName = "-"
+
+var manifestExtensions = []string{".yaml", ".yml", ".json"}
+
 /*
 Deleted - Latest Version
 Deprecated - Current Version Latest Version
@@ -81,6 +101,15 @@ var RootCmd = &cobra.Command{
 	Short:   "ValidateJson a Kubernetes YAML file against the relevant apiVersion and kind",
 	Long:    `ValidateJson a Kubernetes YAML file against the relevant apiVersion and kind, in case the apiVersion for the kind is deprecated or removed then it validates against the latest available apiVersion`,
 	Version: fmt.Sprintf("Version: %s\nCommit: %s\nDate: %s\n", version, commit, date),
+	// files are passed as arguments alongside the subcommands
+	Args: cobra.ArbitraryArgs,
+	// the rules are loaded once for all the manifests the commands validate
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if err := config.LoadRules(); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if config.IgnoreMissingSchemas && !config.Quiet {
 			log2.Warn("Set to ignore missing schemas")
@@ -109,8 +138,18 @@ var RootCmd = &cobra.Command{
 		//}
 		if len(args) > 0 || len(directories) > 0 {
 			success = processFiles(args)
+		} else if input := readRedirectedStdin(); len(input) > 0 {
+			stdinContents = input
+			success = processFiles([]string{stdinFileName})
+		} else if len(clusterSnapshot) > 0 {
+			snapshot, err := pkg.ReadClusterSnapshot(clusterSnapshot)
+			if err != nil {
+				log.Error(err)
+				os.Exit(1)
+			}
+			success = processCluster(snapshot)
 		} else {
-			processCluster()
+			success = processCluster(pkg.NewCluster(kubeconfig, kubecontext))
 		}
 
 		if !success {
@@ -129,9 +168,9 @@ func processFiles(args []string) bool {
 	}
 
 	var aggResults []pkg.ValidationResult
+	stdinName := config.FileName
 	for _, fileName := range files {
-		filePath, _ := filepath.Abs(fileName)
-		fileContents, err := ioutil.ReadFile(filePath)
+		fileContents, err := readFile(fileName)
 		if err != nil {
 			log.Error(fmt.Errorf("Could not open file %v", fileName))
 			earlyExit()
@@ -139,6 +178,9 @@ func processFiles(args []string) bool {
 			continue
 		}
 		config.FileName = fileName
+		if fileName == stdinFileName {
+			config.FileName = stdinName
+		}
 		results, err := kubedd.Validate(fileContents, config)
 		if err != nil {
 			log.Error(err)
@@ -148,7 +190,7 @@ func processFiles(args []string) bool {
 		}
 
 		fmt.Println("")
-		fmt.Printf("Results for file %s\n", fileName)
+		fmt.Printf("Results for file %s\n", config.FileName)
 		fmt.Println("-------------------------------------------")
 		results = removeIgnoredKeys(results)
 		outputManager.PutBulk(results)
@@ -168,17 +210,287 @@ func processFiles(args []string) bool {
 	return success
 }
 
-func processCluster() bool {
+// ChartCmd renders a helm chart with the capabilities of the target kubernetes
+// version and validates the rendered resources
+var ChartCmd = &cobra.Command{
+	Use:   "chart <path>",
+	Short: "Render a helm chart offline and validate the rendered resources",
+	Long:  `Render a helm chart offline with the capabilities of the target kubernetes version, as its api server would report them, and validate the rendered resources against the relevant apiVersion and kind`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if forceColor {
+			color.NoColor = false
+		}
+		if !processChart(args[0]) {
+			os.Exit(1)
+		}
+	},
+}
+
+func processChart(chartPath string) bool {
+	results, err := kubedd.ValidateChart(chartPath, chartOptions, config)
+	if err != nil {
+		log.Error(err)
+		return false
+	}
+	return reportResults(fmt.Sprintf("chart %s", chartPath), results)
+}
+
+// KustomizeCmd builds a kustomization and validates the resulting resources
+var KustomizeCmd = &cobra.Command{
+	Use:   "kustomize <dir>",
+	Short: "Build a kustomization and validate the resulting resources",
+	Long:  `Build a kustomization as kustomize build does and validate the resulting resources against the relevant apiVersion and kind, the patches, replacements and transformer configurations of the kustomization are checked for selectors of removed apiVersions`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if forceColor {
+			color.NoColor = false
+		}
+		if !processKustomization(args[0]) {
+			os.Exit(1)
+		}
+	},
+}
+
+func processKustomization(dir string) bool {
+	results, err := kubedd.ValidateKustomization(dir, config)
+	if err != nil {
+		log.Error(err)
+		return false
+	}
+	return reportResults(fmt.Sprintf("kustomization %s", dir), results)
+}
+
+// reportResults outputs the results of a single source and returns whether
+// they are free of errors
+func reportResults(source string, results []pkg.ValidationResult) bool {
+	success := true
+	outputManager := pkg.GetOutputManager(config.OutputFormat)
+
+	fmt.Println("")
+	fmt.Printf("Results for %s\n", source)
+	fmt.Println("-------------------------------------------")
+	results = removeIgnoredKeys(results)
+	outputManager.PutBulk(results)
+
+	success = success && !hasErrors(results)
+	err := outputManager.Flush()
+	if err != nil {
+		log.Error(err)
+		success = false
+	}
+	return success
+}
+
+// ReleasesCmd validates the manifests stored for the helm releases of a
+// cluster
+var ReleasesCmd = &cobra.Command{
+	Use:   "releases",
+	Short: "Validate the manifests stored for the helm releases of a cluster",
+	Long:  `Validate the manifests helm stored for the latest revision of every release of a cluster, helm fails to upgrade releases whose stored manifests use apiVersions which are no longer served`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if forceColor {
+			color.NoColor = false
+		}
+		if !processReleases() {
+			os.Exit(1)
+		}
+	},
+}
+
+func processReleases() bool {
 	success := true
 	outputManager := pkg.GetOutputManager(config.OutputFormat)
 	cluster := pkg.NewCluster(kubeconfig, kubecontext)
-	results, err := kubedd.ValidateCluster(cluster, config)
+	if cluster == nil {
+		log.Error(fmt.Errorf("could not connect to the cluster of kubeconfig %s", kubeconfig))
+		return false
+	}
+	releaseResults, err := kubedd.ValidateHelmReleases(cluster, config)
+	if err != nil {
+		log.Error(err)
+		return false
+	}
+
+	var aggResults []pkg.ValidationResult
+	for _, releaseResult := range releaseResults {
+		rls := releaseResult.Release
+		fmt.Println("")
+		fmt.Printf("Results for release %s in namespace %s (chart %s, revision %d)\n", rls.Name, rls.Namespace, rls.ChartName(), rls.Version)
+		fmt.Println("-------------------------------------------")
+		results := removeIgnoredKeys(releaseResult.Results)
+		outputManager.PutBulk(results)
+		aggResults = append(aggResults, results...)
+
+		if !fixReleases {
+			continue
+		}
+		fixes, backup, err := kubedd.FixHelmRelease(cluster, releaseResult, releasesBackupDir)
+		if err != nil {
+			log.Error(fmt.Errorf("could not fix release %s in namespace %s: %v", rls.Name, rls.Namespace, err))
+			earlyExit()
+			success = false
+			continue
+		}
+		for _, fix := range fixes {
+			fmt.Printf("Rewrote %s in the stored manifest\n", fix)
+		}
+		if len(backup) > 0 {
+			fmt.Printf("Backed up the release secret to %s\n", backup)
+		}
+	}
+
+	success = success && !hasErrors(aggResults)
+	err = outputManager.Flush()
+	if err != nil {
+		log.Error(err)
+		success = false
+	}
+	return success
+}
+
+// BackupCmd validates the objects of velero backup tarballs offline
+var BackupCmd = &cobra.Command{
+	Use:   "backup <file.tar.gz> [file.tar.gz...]",
+	Short: "Validate the objects of velero backups offline",
+	Long:  `Validate the objects of velero backup tarballs against the target kubernetes version and report the backups whose restore would fail as they contain apiVersions which are removed, grouped by backup and namespace`,
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if forceColor {
+			color.NoColor = false
+		}
+		if !processBackups(args) {
+			os.Exit(1)
+		}
+	},
+}
+
+func processBackups(fileNames []string) bool {
+	success := true
+	outputManager := pkg.GetOutputManager(config.OutputFormat)
+	backupResults, err := kubedd.ValidateVeleroBackups(fileNames, config)
+	if err != nil {
+		log.Error(err)
+		return false
+	}
+	var aggResults []pkg.ValidationResult
+	for _, backupResult := range backupResults {
+		fmt.Println("")
+		fmt.Printf("Results for backup %s\n", backupResult.Name)
+		fmt.Println("-------------------------------------------")
+		results := removeIgnoredKeys(backupResult.Results)
+		outputManager.PutBulk(results)
+		aggResults = append(aggResults, results...)
+	}
+	fmt.Println("")
+	for _, backupResult := range backupResults {
+		removed := backupResult.RemovedAPIs()
+		if len(removed) == 0 {
+			fmt.Printf("Backup %s contains no removed apis\n", backupResult.Name)
+			continue
+		}
+		fmt.Printf("Backup %s contains removed apis\n", backupResult.Name)
+		namespaces := make([]string, 0, len(removed))
+		for namespace := range removed {
+			namespaces = append(namespaces, namespace)
+		}
+		sort.Strings(namespaces)
+		for _, namespace := range namespaces {
+			scope := fmt.Sprintf("namespace %s", namespace)
+			if len(namespace) == 0 {
+				scope = "cluster scoped"
+			}
+			fmt.Printf("  %s: %s\n", scope, strings.Join(removed[namespace], ", "))
+		}
+	}
+	success = success && !hasErrors(aggResults)
+	err = outputManager.Flush()
+	if err != nil {
+		log.Error(err)
+		success = false
+	}
+	return success
+}
+
+// EtcdSnapshotCmd validates the objects stored in an etcd snapshot
+var EtcdSnapshotCmd = &cobra.Command{
+	Use:   "etcd-snapshot <snapshot.db>",
+	Short: "Validate the objects stored in an etcd snapshot offline",
+	Long:  `Validate the objects stored in an etcd snapshot at the apiVersion they are stored at, json or protobuf encoded, which exposes stored versions the api server hides by converting objects when they are read`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if forceColor {
+			color.NoColor = false
+		}
+		if !processEtcdSnapshot(args[0]) {
+			os.Exit(1)
+		}
+	},
+}
+
+func processEtcdSnapshot(fileName string) bool {
+	results, err := kubedd.ValidateEtcdSnapshot(fileName, config)
+	if err != nil {
+		log.Error(err)
+		return false
+	}
+	return reportResults(fmt.Sprintf("etcd snapshot %s", fileName), results)
+}
+
+// AuditCmd reports the requests of apiserver audit logs to deprecated apis
+var AuditCmd = &cobra.Command{
+	Use:   "audit <audit.log> [audit.log...]",
+	Short: "Analyze apiserver audit logs for requests to deprecated apis",
+	Long:  `Analyze apiserver audit logs, json lines of audit.k8s.io/v1 events which may be rotated and gzipped, for requests to apis which are deprecated or not served by the target kubernetes version, grouped by user, user agent, source ip and verb`,
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if forceColor {
+			color.NoColor = false
+		}
+		if !processAuditLogs(args) {
+			os.Exit(1)
+		}
+	},
+}
+
+func processAuditLogs(fileNames []string) bool {
+	usages, err := kubedd.AnalyzeAuditLogs(fileNames, config)
+	if err != nil {
+		log.Error(err)
+		return false
+	}
+	fmt.Println("")
+	fmt.Printf("Results for audit logs %s\n", strings.Join(fileNames, ", "))
+	fmt.Println("-------------------------------------------")
+	if len(usages) == 0 {
+		fmt.Println("No requests to deprecated or removed apis")
+	}
+	outputManager := pkg.GetOutputManager(config.OutputFormat)
+	outputManager.PutSection(pkg.AuditUsageSection(usages))
+	if err := outputManager.Flush(); err != nil {
+		log.Error(err)
+		return false
+	}
+	for _, usage := range usages {
+		if usage.Removed {
+			return false
+		}
+	}
+	return true
+}
+
+func processCluster(cluster pkg.ClusterSource) bool {
+	success := true
+	outputManager := pkg.GetOutputManager(config.OutputFormat)
+	report, err := kubedd.ScanCluster(cluster, config)
 	if err != nil {
 		log.Error(err)
 		earlyExit()
 		success = false
 		return success
 	}
+	results := report.Results
 
 	serverVersion, _ := cluster.ServerVersion()
 	fmt.Println("")
@@ -186,6 +498,8 @@ func processCluster() bool {
 	fmt.Println("-------------------------------------------")
 	results = removeIgnoredKeys(results)
 	outputManager.PutBulk(results)
+	outputManager.PutSection(pkg.ManagerUsageSection(report.ManagerUsages))
+	outputManager.PutSection(pkg.RequestedAPIUsageSection(report.RequestedAPIs))
 
 	//aggResults = append(aggResults, results...)
 	success = success && !hasErrors(results)
@@ -197,6 +511,40 @@ func processCluster() bool {
 	return success
 }
 
+// SnapshotCmd writes a snapshot of a cluster, which is validated offline with
+// the --snapshot flag
+var SnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Capture a snapshot of a cluster to validate it offline",
+	Long:  `Capture the objects kubedd validates for a cluster, along with its server version, discovery data and CRDs, to a gzipped tarball. The data of secrets is redacted. The snapshot is validated offline with kubedd --snapshot <file>, without any access to the cluster`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !processSnapshot() {
+			os.Exit(1)
+		}
+	},
+}
+
+func processSnapshot() bool {
+	cluster := pkg.NewCluster(kubeconfig, kubecontext)
+	if cluster == nil {
+		log.Error(fmt.Errorf("could not connect to the cluster of kubeconfig %s", kubeconfig))
+		return false
+	}
+	snapshot, err := kubedd.SnapshotCluster(cluster, config)
+	if err != nil {
+		log.Error(err)
+		return false
+	}
+	if err := snapshot.WriteFile(snapshotOutput); err != nil {
+		log.Error(err)
+		return false
+	}
+	serverVersion, _ := snapshot.ServerVersion()
+	fmt.Printf("Wrote the snapshot of the cluster at version %s to %s\n", serverVersion, snapshotOutput)
+	return true
+}
+
 func removeIgnoredKeys(results []pkg.ValidationResult) []pkg.ValidationResult {
 	var out []pkg.ValidationResult
 	for _, result := range results {
@@ -221,7 +569,7 @@ func removeIgnoredKeys(results []pkg.ValidationResult) []pkg.ValidationResult {
 			result.DeprecationForLatest = depErr
 		}
 		if len(result.ErrorsForOriginal) > 0 {
-			var valErr []*openapi3.SchemaError
+			var valErr []*pkg.SchemaError
 			for _, schemaError := range result.ErrorsForOriginal {
 				key := strings.Join(schemaError.JSONPointer(), "/")
 				if !pkg.Contains(key, ignoreKeysFromValidation) {
@@ -231,7 +579,7 @@ func removeIgnoredKeys(results []pkg.ValidationResult) []pkg.ValidationResult {
 			result.ErrorsForOriginal = valErr
 		}
 		if len(result.ErrorsForLatest) > 0 {
-			var valErr []*openapi3.SchemaError
+			var valErr []*pkg.SchemaError
 			for _, schemaError := range result.ErrorsForLatest {
 				key := strings.Join(schemaError.JSONPointer(), "/")
 				if !pkg.Contains(key, ignoreKeysFromValidation) {
@@ -240,6 +588,26 @@ func removeIgnoredKeys(results []pkg.ValidationResult) []pkg.ValidationResult {
 			}
 			result.ErrorsForLatest = valErr
 		}
+		if len(result.WarningsForOriginal) > 0 {
+			var valWarn []*pkg.SchemaError
+			for _, schemaError := range result.WarningsForOriginal {
+				key := strings.Join(schemaError.JSONPointer(), "/")
+				if !pkg.Contains(key, ignoreKeysFromValidation) {
+					valWarn = append(valWarn, schemaError)
+				}
+			}
+			result.WarningsForOriginal = valWarn
+		}
+		if len(result.WarningsForLatest) > 0 {
+			var valWarn []*pkg.SchemaError
+			for _, schemaError := range result.WarningsForLatest {
+				key := strings.Join(schemaError.JSONPointer(), "/")
+				if !pkg.Contains(key, ignoreKeysFromValidation) {
+					valWarn = append(valWarn, schemaError)
+				}
+			}
+			result.WarningsForLatest = valWarn
+		}
 		out = append(out, result)
 	}
 	return out
@@ -252,6 +620,14 @@ func hasErrors(res []pkg.ValidationResult) bool {
 		if len(r.ErrorsForOriginal) > 0 || len(r.ErrorsForLatest) > 0 {
 			return true
 		}
+		for _, e := range append(r.DeprecationForOriginal, r.DeprecationForLatest...) {
+			if e.Severity == pkg.SeverityError {
+				return true
+			}
+		}
+		if !r.ValidatedAgainstSchema && len(r.Kind) > 0 && !config.IgnoreMissingSchemas {
+			return true
+		}
 	}
 	return false
 }
@@ -270,6 +646,68 @@ func isIgnored(path string) (bool, error) {
 	return false, nil
 }
 
+// readFile reads the manifests of fileName, or those piped to kubedd if
+// fileName is -
+func readFile(fileName string) ([]byte, error) {
+	if fileName != stdinFileName {
+		filePath, _ := filepath.Abs(fileName)
+		return ioutil.ReadFile(filePath)
+	}
+	if stdinContents == nil {
+		input, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		stdinContents = input
+	}
+	return stdinContents, nil
+}
+
+// readRedirectedStdin reads the manifests of a file redirected to kubedd as
+// stdin. Pipes are left alone as CI runners, docker run -i or ssh often leave
+// stdin open as a pipe which is never closed, manifests are piped to kubedd
+// with - as the file name
+func readRedirectedStdin() []byte {
+	if len(kubeconfig) > 0 || len(clusterSnapshot) > 0 {
+		return nil
+	}
+	stat, err := os.Stdin.Stat()
+	if err != nil || !stat.Mode().IsRegular() {
+		return nil
+	}
+	input, err := ioutil.ReadAll(os.Stdin)
+	if err != nil || len(bytes.TrimSpace(input)) == 0 {
+		return nil
+	}
+	return input
+}
+
+// isManifestFile checks whether the file found in a directory is a yaml or
+// json manifest
+func isManifestFile(name string) bool {
+	for _, extension := range manifestExtensions {
+		if strings.HasSuffix(name, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// isManifestContent skips the json files found in directories which are not
+// kubernetes objects, e.g. package.json or tsconfig.json. Files passed as
+// arguments are always validated, so their parse errors are reported
+func isManifestContent(path string) bool {
+	if !strings.HasSuffix(path, ".json") {
+		return true
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		// reported when the file is read for validation
+		return true
+	}
+	return kubedd.IsJSONManifest(data)
+}
+
 func aggregateFiles(args []string) ([]string, error) {
 	files := make([]string, len(args))
 	copy(files, args)
@@ -284,7 +722,7 @@ func aggregateFiles(args []string) ([]string, error) {
 			if err != nil {
 				return err
 			}
-			if !info.IsDir() && (strings.HasSuffix(info.Name(), ".yaml") || strings.HasSuffix(info.Name(), ".yml")) && !ignored {
+			if !info.IsDir() && isManifestFile(info.Name()) && !ignored && isManifestContent(path) {
 				files = append(files, path)
 			}
 			return nil
@@ -321,11 +759,54 @@ func init() {
 	pkg.AddKubeaddFlags(RootCmd, config)
 	RootCmd.Flags().BoolVarP(&forceColor, "force-color", "", false, "Force colored output even if stdout is not a TTY")
 	RootCmd.SetVersionTemplate(`{{.Version}}`)
-	RootCmd.Flags().StringSliceVarP(&directories, "directories", "d", []string{}, "A comma-separated list of directories to recursively search for YAML documents")
+	RootCmd.Flags().StringSliceVarP(&directories, "directories", "d", []string{}, "A comma-separated list of directories to recursively search for YAML and JSON manifests")
 	RootCmd.Flags().StringSliceVarP(&ignoredPathPatterns, "ignored-path-patterns", "i", []string{}, "A comma-separated list of regular expressions specifying paths to ignore")
 	RootCmd.Flags().StringSliceVarP(&ignoredPathPatterns, "ignored-filename-patterns", "", []string{}, "An alias for ignored-path-patterns")
 	RootCmd.Flags().StringVarP(&kubeconfig, "kubeconfig", "", "", "Path of kubeconfig file of cluster to be scanned")
 	RootCmd.Flags().StringVarP(&kubecontext, "kubecontext", "", "", "Kubecontext to be selected")
+	RootCmd.Flags().StringVarP(&clusterSnapshot, "snapshot", "", "", "Path of a cluster snapshot written by kubedd snapshot to be scanned instead of a cluster")
+	RootCmd.Flags().StringVarP(&config.MetricsFile, "metrics-file", "", "", "Path of the apiserver metrics saved by kubectl get --raw /metrics, read for the deprecated apis in active use instead of scraping the cluster")
+
+	pkg.AddValidationFlags(ChartCmd, config)
+	ChartCmd.Flags().BoolVarP(&forceColor, "force-color", "", false, "Force colored output even if stdout is not a TTY")
+	ChartCmd.Flags().StringSliceVarP(&chartOptions.ValueFiles, "values", "f", []string{}, "Values files of the chart, merged in order")
+	ChartCmd.Flags().StringArrayVarP(&chartOptions.Values, "set", "", []string{}, "Values of the chart set as key1=val1,key2=val2, as helm --set does")
+	ChartCmd.Flags().StringArrayVarP(&chartOptions.StringValues, "set-string", "", []string{}, "String values of the chart set as key1=val1,key2=val2, as helm --set-string does")
+	ChartCmd.Flags().StringVarP(&chartOptions.ReleaseName, "release-name", "", "", "Name of the release the chart is rendered for, the chart name by default")
+	ChartCmd.Flags().StringVarP(&chartOptions.Namespace, "namespace", "n", "", "Namespace of the release the chart is rendered for")
+	RootCmd.AddCommand(ChartCmd)
+
+	pkg.AddValidationFlags(KustomizeCmd, config)
+	KustomizeCmd.Flags().BoolVarP(&forceColor, "force-color", "", false, "Force colored output even if stdout is not a TTY")
+	RootCmd.AddCommand(KustomizeCmd)
+
+	pkg.AddValidationFlags(ReleasesCmd, config)
+	ReleasesCmd.Flags().BoolVarP(&forceColor, "force-color", "", false, "Force colored output even if stdout is not a TTY")
+	ReleasesCmd.Flags().StringVarP(&kubeconfig, "kubeconfig", "", "", "Path of kubeconfig file of cluster to be scanned")
+	ReleasesCmd.Flags().StringVarP(&kubecontext, "kubecontext", "", "", "Kubecontext to be selected")
+	ReleasesCmd.Flags().BoolVarP(&fixReleases, "fix-releases", "", false, "Rewrite the deprecated and removed apiVersions of the stored manifests to their latest versions")
+	ReleasesCmd.Flags().StringVarP(&releasesBackupDir, "releases-backup-dir", "", "release-backups", "Directory the release secrets are backed up to before they are rewritten")
+	RootCmd.AddCommand(ReleasesCmd)
+
+	pkg.AddValidationFlags(BackupCmd, config)
+	BackupCmd.Flags().BoolVarP(&forceColor, "force-color", "", false, "Force colored output even if stdout is not a TTY")
+	RootCmd.AddCommand(BackupCmd)
+
+	pkg.AddValidationFlags(EtcdSnapshotCmd, config)
+	EtcdSnapshotCmd.Flags().BoolVarP(&forceColor, "force-color", "", false, "Force colored output even if stdout is not a TTY")
+	RootCmd.AddCommand(EtcdSnapshotCmd)
+
+	SnapshotCmd.Flags().StringVarP(&kubeconfig, "kubeconfig", "", "", "Path of kubeconfig file of cluster to be captured")
+	SnapshotCmd.Flags().StringVarP(&kubecontext, "kubecontext", "", "", "Kubecontext to be selected")
+	SnapshotCmd.Flags().StringVarP(&snapshotOutput, "output", "o", "snapshot.tar.gz", "Path of the file the snapshot is written to")
+	SnapshotCmd.Flags().StringVarP(&config.SourceSchemaLocation, "source-schema-location", "", "", "SourceSchemaLocation is the base URL of the kubernetes version of the cluster.")
+	RootCmd.AddCommand(SnapshotCmd)
+
+	AuditCmd.Flags().BoolVarP(&forceColor, "force-color", "", false, "Force colored output even if stdout is not a TTY")
+	AuditCmd.Flags().StringVarP(&config.TargetKubernetesVersion, "target-kubernetes-version", "", "1.22", "Version of Kubernetes to migrate to")
+	AuditCmd.Flags().StringVarP(&config.TargetSchemaLocation, "target-schema-location", "", "", "TargetSchemaLocation is the base URL of target kubernetes version.")
+	AuditCmd.Flags().StringVarP(&config.OutputFormat, "output", "o", "", "The format of the output of this script. Options are: [stdout json tap]")
+	RootCmd.AddCommand(AuditCmd)
 
 	viper.SetEnvPrefix("KUBEADD")
 	viper.AutomaticEnv()
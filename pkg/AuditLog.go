@@ -0,0 +1,290 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devtron-labs/deprecation-checker/pkg/log"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// auditDeprecatedAnnotation and auditRemovedReleaseAnnotation are set by
+	// the apiserver on the audit events of requests to deprecated apis
+	auditDeprecatedAnnotation     = "k8s.io/deprecated"
+	auditRemovedReleaseAnnotation = "k8s.io/removed-release"
+	// maxAuditEventSize is the size of the largest audit event read, events
+	// at the RequestResponse level embed whole objects
+	maxAuditEventSize = 16 * 1024 * 1024
+	// auditStageResponseComplete and auditStagePanic are the last stages a
+	// request is logged at
+	auditStageResponseComplete = "ResponseComplete"
+	auditStagePanic            = "Panic"
+)
+
+// auditEvent are the fields of an audit.k8s.io/v1 Event which are analyzed
+type auditEvent struct {
+	AuditID string `json:"auditID"`
+	Stage   string `json:"stage"`
+	Verb    string `json:"verb"`
+	User    struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	SourceIPs                []string          `json:"sourceIPs"`
+	UserAgent                string            `json:"userAgent"`
+	ObjectRef                *auditObjectRef   `json:"objectRef"`
+	RequestReceivedTimestamp time.Time         `json:"requestReceivedTimestamp"`
+	Annotations              map[string]string `json:"annotations"`
+}
+
+type auditObjectRef struct {
+	Resource   string `json:"resource"`
+	APIGroup   string `json:"apiGroup"`
+	APIVersion string `json:"apiVersion"`
+}
+
+// AuditUsage are the requests of a client to an api which is deprecated, or
+// removed in the target kubernetes version, as recorded in audit logs
+type AuditUsage struct {
+	User       string
+	UserAgent  string
+	SourceIP   string
+	Verb       string
+	APIVersion string
+	Resource   string
+	// LatestAPIVersion is the apiVersion the resource is served at by the
+	// target version, if known
+	LatestAPIVersion string
+	// RemovedRelease is the release the api is removed in as announced by the
+	// apiserver, if known
+	RemovedRelease string
+	Removed        bool
+	Deprecated     bool
+	Requests       int
+	FirstSeen      time.Time
+	LastSeen       time.Time
+}
+
+// auditAnalyzer matches the requests of audit events against the apis served
+// by the target version
+type auditAnalyzer struct {
+	served map[schema.GroupVersionResource]schema.GroupVersionKind
+	latest map[string]schema.GroupVersionKind
+	seen   map[string]bool
+	usages map[string]*AuditUsage
+}
+
+// AnalyzeAuditLogs reads the audit.k8s.io/v1 events of the json lines audit
+// logs fileNames, which may be gzipped as rotated logs often are, and reports
+// the requests to apis the apiserver flagged as deprecated or which
+// targetVersion does not serve. Requests are grouped by user, user agent,
+// source ip, verb and api
+func AnalyzeAuditLogs(fileNames []string, kubeC KubeChecker, targetVersion string) ([]AuditUsage, error) {
+	served, err := kubeC.GetServedResources(targetVersion)
+	if err != nil {
+		return nil, err
+	}
+	latestKinds, err := kubeC.GetKinds(targetVersion)
+	if err != nil {
+		return nil, err
+	}
+	a := &auditAnalyzer{
+		served: served,
+		latest: map[string]schema.GroupVersionKind{},
+		seen:   map[string]bool{},
+		usages: map[string]*AuditUsage{},
+	}
+	for _, gvk := range latestKinds {
+		a.latest[strings.ToLower(gvk.Kind)] = gvk
+	}
+	for _, fileName := range fileNames {
+		if err := a.readFile(fileName); err != nil {
+			return nil, fmt.Errorf("could not read audit log %s: %v", fileName, err)
+		}
+	}
+	return a.result(), nil
+}
+
+func (a *auditAnalyzer) readFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	var input io.Reader = reader
+	if magic, err := reader.Peek(len(magicGzip)); err == nil && bytes.Equal(magic, magicGzip) {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		input = gz
+	}
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), maxAuditEventSize)
+	invalid := 0
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var event auditEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			invalid++
+			continue
+		}
+		a.add(&event)
+	}
+	if invalid > 0 {
+		log.Warn(fmt.Sprintf("skipped %d lines of %s which are not audit events", invalid, fileName))
+	}
+	return scanner.Err()
+}
+
+// add records event if it requests a deprecated or removed api. Events are
+// logged once per stage of a request, a request is counted once by its id.
+// Only the ids of matching requests which have not reached their last stage
+// are remembered, so memory does not grow with the size of the logs
+func (a *auditAnalyzer) add(event *auditEvent) {
+	ref := event.ObjectRef
+	if ref == nil || len(ref.Resource) == 0 || len(ref.APIVersion) == 0 {
+		return
+	}
+	gvr := schema.GroupVersionResource{Group: ref.APIGroup, Version: ref.APIVersion, Resource: ref.Resource}
+	deprecated := event.Annotations[auditDeprecatedAnnotation] == "true"
+	_, isServed := a.served[gvr]
+	latest, isKnown := a.latestVersion(gvr)
+	removed := !isServed && (isKnown || deprecated)
+	if !removed && !deprecated {
+		return
+	}
+	if len(event.AuditID) > 0 {
+		counted := a.seen[event.AuditID]
+		if event.Stage == auditStageResponseComplete || event.Stage == auditStagePanic {
+			delete(a.seen, event.AuditID)
+		} else {
+			a.seen[event.AuditID] = true
+		}
+		if counted {
+			return
+		}
+	}
+	sourceIP := strings.Join(event.SourceIPs, ",")
+	apiVersion := gvr.GroupVersion().String()
+	key := strings.Join([]string{event.User.Username, event.UserAgent, sourceIP, event.Verb, apiVersion, gvr.Resource}, "\x00")
+	usage, ok := a.usages[key]
+	if !ok {
+		usage = &AuditUsage{
+			User:       event.User.Username,
+			UserAgent:  event.UserAgent,
+			SourceIP:   sourceIP,
+			Verb:       event.Verb,
+			APIVersion: apiVersion,
+			Resource:   gvr.Resource,
+			FirstSeen:  event.RequestReceivedTimestamp,
+			LastSeen:   event.RequestReceivedTimestamp,
+		}
+		if latest != apiVersion {
+			usage.LatestAPIVersion = latest
+		}
+		a.usages[key] = usage
+	}
+	usage.Requests++
+	usage.Removed = usage.Removed || removed
+	usage.Deprecated = usage.Deprecated || deprecated
+	if release, ok := event.Annotations[auditRemovedReleaseAnnotation]; ok {
+		usage.RemovedRelease = release
+	}
+	if event.RequestReceivedTimestamp.Before(usage.FirstSeen) {
+		usage.FirstSeen = event.RequestReceivedTimestamp
+	}
+	if event.RequestReceivedTimestamp.After(usage.LastSeen) {
+		usage.LastSeen = event.RequestReceivedTimestamp
+	}
+}
+
+// latestVersion returns the latest apiVersion of the kind served as the
+// resource of gvr by the target version, preferring the group of gvr as
+// resources move between groups, e.g. extensions/v1beta1 ingresses
+func (a *auditAnalyzer) latestVersion(gvr schema.GroupVersionResource) (string, bool) {
+	var kind string
+	for served, gvk := range a.served {
+		if served.Resource != gvr.Resource {
+			continue
+		}
+		if len(kind) == 0 || served.Group == gvr.Group {
+			kind = gvk.Kind
+		}
+	}
+	if len(kind) == 0 {
+		return "", false
+	}
+	latest, ok := a.latest[strings.ToLower(kind)]
+	if !ok {
+		return "", true
+	}
+	return latest.GroupVersion().String(), true
+}
+
+func (a *auditAnalyzer) result() []AuditUsage {
+	usages := make([]AuditUsage, 0, len(a.usages))
+	for _, usage := range a.usages {
+		usages = append(usages, *usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		x, y := usages[i], usages[j]
+		for _, pair := range [][2]string{{x.User, y.User}, {x.UserAgent, y.UserAgent}, {x.SourceIP, y.SourceIP}, {x.Verb, y.Verb}, {x.APIVersion, y.APIVersion}} {
+			if pair[0] != pair[1] {
+				return pair[0] < pair[1]
+			}
+		}
+		return x.Resource < y.Resource
+	})
+	return usages
+}
+
+// AuditUsageSection reports usages as a section, see AnalyzeAuditLogs
+func AuditUsageSection(usages []AuditUsage) Section {
+	section := Section{
+		Title:   "Requests to deprecated or removed API Version's",
+		Headers: []string{"User", "User Agent", "Source IP", "Verb", "Resource", "API Version (Requested)", "Replace With API Version (Latest Available)", "Status", "Requests", "First Seen", "Last Seen"},
+	}
+	for _, usage := range usages {
+		status := "deprecated"
+		if usage.Removed {
+			status = "removed"
+		} else if len(usage.RemovedRelease) > 0 {
+			status = fmt.Sprintf("deprecated, removed in %s", usage.RemovedRelease)
+		}
+		section.Rows = append(section.Rows, []string{usage.User, usage.UserAgent, usage.SourceIP, usage.Verb, usage.Resource,
+			usage.APIVersion, usage.LatestAPIVersion, status, fmt.Sprintf("%d", usage.Requests),
+			usage.FirstSeen.Format(time.RFC3339), usage.LastSeen.Format(time.RFC3339)})
+	}
+	return section
+}
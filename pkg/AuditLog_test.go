@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const auditLog = `{"kind":"Event","apiVersion":"audit.k8s.io/v1","auditID":"1","stage":"RequestReceived","verb":"list","user":{"username":"ci"},"sourceIPs":["10.0.0.1"],"userAgent":"kubectl/v1.15.0","objectRef":{"resource":"deployments","namespace":"apps","apiGroup":"extensions","apiVersion":"v1beta1"},"requestReceivedTimestamp":"2021-10-01T10:00:00.000000Z","annotations":{"k8s.io/deprecated":"true","k8s.io/removed-release":"1.16"}}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","auditID":"1","stage":"ResponseComplete","verb":"list","user":{"username":"ci"},"sourceIPs":["10.0.0.1"],"userAgent":"kubectl/v1.15.0","objectRef":{"resource":"deployments","namespace":"apps","apiGroup":"extensions","apiVersion":"v1beta1"},"requestReceivedTimestamp":"2021-10-01T10:00:00.000000Z","annotations":{"k8s.io/deprecated":"true","k8s.io/removed-release":"1.16"}}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","auditID":"2","stage":"ResponseComplete","verb":"list","user":{"username":"ci"},"sourceIPs":["10.0.0.1"],"userAgent":"kubectl/v1.15.0","objectRef":{"resource":"deployments","namespace":"apps","apiGroup":"extensions","apiVersion":"v1beta1"},"requestReceivedTimestamp":"2021-10-02T10:00:00.000000Z","annotations":{"k8s.io/deprecated":"true","k8s.io/removed-release":"1.16"}}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","auditID":"3","stage":"ResponseComplete","verb":"get","user":{"username":"ci"},"sourceIPs":["10.0.0.1"],"userAgent":"kubectl/v1.22.0","objectRef":{"resource":"deployments","namespace":"apps","name":"web","apiGroup":"apps","apiVersion":"v1"},"requestReceivedTimestamp":"2021-10-02T11:00:00.000000Z"}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","auditID":"4","stage":"ResponseComplete","verb":"get","user":{"username":"operator"},"sourceIPs":["10.0.0.2"],"userAgent":"widget-operator","objectRef":{"resource":"widgets","apiGroup":"example.com","apiVersion":"v1alpha1"},"requestReceivedTimestamp":"2021-10-02T11:00:00.000000Z"}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","auditID":"5","stage":"ResponseComplete","verb":"get","user":{"username":"admin"},"sourceIPs":["10.0.0.3"],"userAgent":"curl","requestURI":"/healthz","requestReceivedTimestamp":"2021-10-02T11:00:00.000000Z"}
+{"kind":"Event","apiVers`
+
+const rotatedAuditLog = `{"kind":"Event","apiVersion":"audit.k8s.io/v1","auditID":"6","stage":"ResponseComplete","verb":"update","user":{"username":"system:serviceaccount:apps:deployer"},"sourceIPs":["10.0.0.4"],"userAgent":"deployer/v1","objectRef":{"resource":"deployments","namespace":"apps","name":"web","apiGroup":"extensions","apiVersion":"v1beta1"},"requestReceivedTimestamp":"2021-09-30T10:00:00.000000Z"}
+`
+
+func TestAnalyzeAuditLogs(t *testing.T) {
+	dir := t.TempDir()
+	current := filepath.Join(dir, "audit.log")
+	if err := ioutil.WriteFile(current, []byte(auditLog), 0600); err != nil {
+		t.Fatal(err)
+	}
+	rotated := filepath.Join(dir, "audit.log.1.gz")
+	file, err := os.Create(rotated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(file)
+	if _, err := gz.Write([]byte(rotatedAuditLog)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	usages, err := AnalyzeAuditLogs([]string{current, rotated}, testKubeChecker(t), "1.22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AuditUsage{
+		{
+			User: "ci", UserAgent: "kubectl/v1.15.0", SourceIP: "10.0.0.1", Verb: "list",
+			APIVersion: "extensions/v1beta1", Resource: "deployments", LatestAPIVersion: "apps/v1", RemovedRelease: "1.16",
+			Removed: true, Deprecated: true, Requests: 2,
+			FirstSeen: time.Date(2021, 10, 1, 10, 0, 0, 0, time.UTC), LastSeen: time.Date(2021, 10, 2, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			User: "system:serviceaccount:apps:deployer", UserAgent: "deployer/v1", SourceIP: "10.0.0.4", Verb: "update",
+			APIVersion: "extensions/v1beta1", Resource: "deployments", LatestAPIVersion: "apps/v1",
+			Removed: true, Requests: 1,
+			FirstSeen: time.Date(2021, 9, 30, 10, 0, 0, 0, time.UTC), LastSeen: time.Date(2021, 9, 30, 10, 0, 0, 0, time.UTC),
+		},
+	}
+	if !reflect.DeepEqual(usages, want) {
+		t.Errorf("AnalyzeAuditLogs() = %+v, want %+v", usages, want)
+	}
+	section := AuditUsageSection(usages)
+	if len(section.Rows) != 2 || strings.Join(section.Rows[0][7:], " ") != "removed 2 2021-10-01T10:00:00Z 2021-10-02T10:00:00Z" {
+		t.Errorf("AuditUsageSection() = %v", section.Rows)
+	}
+	if _, err := AnalyzeAuditLogs([]string{filepath.Join(dir, "missing.log")}, testKubeChecker(t), "1.22"); err == nil {
+		t.Error("AnalyzeAuditLogs() of a missing log should fail")
+	}
+}
+
+func TestAuditAnalyzer_seen(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "audit.log")
+	if err := ioutil.WriteFile(fileName, []byte(auditLog), 0600); err != nil {
+		t.Fatal(err)
+	}
+	a := &auditAnalyzer{
+		served: map[schema.GroupVersionResource]schema.GroupVersionKind{},
+		latest: map[string]schema.GroupVersionKind{},
+		seen:   map[string]bool{},
+		usages: map[string]*AuditUsage{},
+	}
+	if err := a.readFile(fileName); err != nil {
+		t.Fatal(err)
+	}
+	if len(a.seen) != 0 {
+		t.Errorf("auditAnalyzer.seen = %v, want the ids of completed and unmatched requests forgotten", a.seen)
+	}
+}
@@ -3,6 +3,7 @@ package pkg
 import (
 	"context"
 	"fmt"
+	"github.com/devtron-labs/deprecation-checker/pkg/log"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -64,45 +65,91 @@ func (c *Cluster) ServerVersion() (string, error) {
 	return fmt.Sprintf("%s.%s", info.Major, info.Minor), nil
 }
 func (c *Cluster) FetchK8sObjects(gvks []schema.GroupVersionKind, conf *Config) []unstructured.Unstructured {
-	var resources []schema.GroupVersionResource
-	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(c.disco))
 	var objs []unstructured.Unstructured
-	for _, gvk := range gvks {
-		if Contains(gvk.Kind, conf.IgnoreKinds) {
+	for _, resource := range c.listedResources(gvks, conf) {
+		objList, err := c.listObjects(resource, conf)
+		if err != nil {
+			log.Warn(fmt.Sprintf("skipped resource %v which could not be listed: %v", resource, err))
 			continue
 		}
-		if len(conf.SelectKinds) > 0 && !Contains(gvk.Kind, conf.SelectKinds) {
+		objs = append(objs, objList...)
+	}
+	return objs
+}
+
+// listedResources maps the selected kinds of gvks to the resources they are
+// listed from
+func (c *Cluster) listedResources(gvks []schema.GroupVersionKind, conf *Config) []schema.GroupVersionResource {
+	var resources []schema.GroupVersionResource
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(c.disco))
+	for _, gvk := range gvks {
+		if !isKindSelected(gvk.Kind, conf) {
 			continue
 		}
 		gvr, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
 		if err != nil {
 			continue
 		}
+		if isUnlistedResource(gvr.Resource) {
+			continue
+		}
 		resources = append(resources, gvr.Resource)
 	}
-	for _, resource := range resources {
-		if strings.Contains(resource.Resource, "lists") ||  strings.Contains(resource.Resource, "reviews") || strings.EqualFold(resource.Resource, "bindings") {
+	return resources
+}
+
+// listObjects lists the objects of resource in the selected namespaces
+func (c *Cluster) listObjects(resource schema.GroupVersionResource, conf *Config) ([]unstructured.Unstructured, error) {
+	objList, err := c.clientset.Resource(resource).List(context.Background(), v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return selectNamespaces(objList.Items, conf), nil
+}
+
+func isKindSelected(kind string, conf *Config) bool {
+	if Contains(kind, conf.IgnoreKinds) {
+		return false
+	}
+	return len(conf.SelectKinds) == 0 || Contains(kind, conf.SelectKinds)
+}
+
+// isUnlistedResource checks whether resource is not listed, as the reviews and
+// bindings which only support create
+func isUnlistedResource(resource schema.GroupVersionResource) bool {
+	return strings.Contains(resource.Resource, "lists") || strings.Contains(resource.Resource, "reviews") || strings.EqualFold(resource.Resource, "bindings")
+}
+
+// selectNamespaces returns the objects of the selected namespaces, cluster
+// scoped objects are considered to be in the default namespace
+func selectNamespaces(objs []unstructured.Unstructured, conf *Config) []unstructured.Unstructured {
+	var selected []unstructured.Unstructured
+	for _, obj := range objs {
+		namespace := obj.GetNamespace()
+		if len(obj.GetNamespace()) == 0 {
+			namespace = "default"
+		}
+		if Contains(namespace, conf.IgnoreNamespaces) {
 			continue
 		}
-		resInf := c.clientset.Resource(resource)
-		objList, err := resInf.List(context.Background(), v1.ListOptions{})
-		if err != nil {
-			fmt.Printf("err while fetching resource %v error %v\n", resource, err)
+		if len(conf.SelectNamespaces) > 0 && !Contains(namespace, conf.SelectNamespaces) {
 			continue
 		}
-		for _, obj := range objList.Items {
-			namespace := obj.GetNamespace()
-			if len(obj.GetNamespace()) == 0 {
-				namespace = "default"
-			}
-			if Contains(namespace, conf.IgnoreNamespaces) {
-				continue
-			}
-			if len(conf.SelectNamespaces) > 0 && !Contains(namespace, conf.SelectNamespaces) {
-				continue
-			}
-			objs = append(objs, obj)
+		selected = append(selected, obj)
+	}
+	return selected
+}
+
+// FetchCRDs lists the CustomResourceDefinitions of the cluster, so that the
+// custom resources can be validated against their schemas
+func (c *Cluster) FetchCRDs() []unstructured.Unstructured {
+	for _, version := range []string{"v1", "v1beta1"} {
+		resource := schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: version, Resource: "customresourcedefinitions"}
+		objList, err := c.clientset.Resource(resource).List(context.Background(), v1.ListOptions{})
+		if err != nil {
+			continue
 		}
+		return objList.Items
 	}
-	return objs
-}
\ No newline at end of file
+	return nil
+}
@@ -0,0 +1,326 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/devtron-labs/deprecation-checker/pkg/log"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+)
+
+// ClusterSource is the source of the objects of a cluster which are
+// validated, a live cluster or a snapshot of it
+type ClusterSource interface {
+	ServerVersion() (string, error)
+	FetchCRDs() []unstructured.Unstructured
+	FetchK8sObjects(gvks []schema.GroupVersionKind, conf *Config) []unstructured.Unstructured
+}
+
+const (
+	snapshotVersionFile   = "version.json"
+	snapshotDiscoveryFile = "discovery.json"
+	snapshotCRDsFile      = "crds.json"
+	snapshotResourcesDir  = "resources"
+	// snapshotCoreGroup names the directory of the core group
+	snapshotCoreGroup = "core"
+)
+
+const lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// ClusterSnapshot holds the server version, discovery data, CRDs and objects
+// of a cluster, so that it is validated offline as the cluster would be
+type ClusterSnapshot struct {
+	Version   *version.Info
+	Resources []*v1.APIResourceList
+	CRDs      []unstructured.Unstructured
+	Objects   map[schema.GroupVersionResource][]unstructured.Unstructured
+}
+
+// snapshotList is the json a list of objects of a snapshot is stored as
+type snapshotList struct {
+	APIVersion string                      `json:"apiVersion"`
+	Kind       string                      `json:"kind"`
+	Items      []unstructured.Unstructured `json:"items"`
+}
+
+// Snapshot captures the objects FetchK8sObjects lists for gvks in any
+// namespace, along with the server version, discovery data and CRDs. The
+// data of secrets is redacted
+func (c *Cluster) Snapshot(gvks []schema.GroupVersionKind) (*ClusterSnapshot, error) {
+	info, err := c.disco.ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+	_, resources, err := c.disco.ServerGroupsAndResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, err
+	}
+	snapshot := &ClusterSnapshot{
+		Version:   info,
+		Resources: resources,
+		CRDs:      c.FetchCRDs(),
+		Objects:   map[schema.GroupVersionResource][]unstructured.Unstructured{},
+	}
+	conf := &Config{}
+	for _, resource := range c.listedResources(gvks, conf) {
+		objs, err := c.listObjects(resource, conf)
+		if err != nil {
+			log.Warn(fmt.Sprintf("skipped resource %v which could not be listed: %v", resource, err))
+			continue
+		}
+		for i := range objs {
+			redactSecret(&objs[i])
+		}
+		snapshot.Objects[resource] = append(snapshot.Objects[resource], objs...)
+	}
+	return snapshot, nil
+}
+
+// ServerVersion returns the major and minor version of the snapshotted cluster
+func (s *ClusterSnapshot) ServerVersion() (string, error) {
+	if s.Version == nil {
+		return "", fmt.Errorf("the snapshot has no server version")
+	}
+	return fmt.Sprintf("%s.%s", s.Version.Major, s.Version.Minor), nil
+}
+
+// FetchCRDs returns the CustomResourceDefinitions of the snapshotted cluster
+func (s *ClusterSnapshot) FetchCRDs() []unstructured.Unstructured {
+	return s.CRDs
+}
+
+// FetchK8sObjects returns the snapshotted objects Cluster.FetchK8sObjects
+// would list for gvks
+func (s *ClusterSnapshot) FetchK8sObjects(gvks []schema.GroupVersionKind, conf *Config) []unstructured.Unstructured {
+	var objs []unstructured.Unstructured
+	for _, gvk := range gvks {
+		if !isKindSelected(gvk.Kind, conf) {
+			continue
+		}
+		resource, ok := s.resourceOf(gvk)
+		if !ok || isUnlistedResource(resource) {
+			continue
+		}
+		objs = append(objs, selectNamespaces(s.Objects[resource], conf)...)
+	}
+	return objs
+}
+
+// resourceOf returns the resource gvk is served as by the snapshotted cluster
+func (s *ClusterSnapshot) resourceOf(gvk schema.GroupVersionKind) (schema.GroupVersionResource, bool) {
+	for _, list := range s.Resources {
+		if list.GroupVersion != gvk.GroupVersion().String() {
+			continue
+		}
+		for _, resource := range list.APIResources {
+			if strings.Contains(resource.Name, "/") || !strings.EqualFold(resource.Kind, gvk.Kind) {
+				continue
+			}
+			return gvk.GroupVersion().WithResource(resource.Name), true
+		}
+	}
+	return schema.GroupVersionResource{}, false
+}
+
+// Write writes the snapshot as a gzipped tarball
+func (s *ClusterSnapshot) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+	files := map[string]interface{}{
+		snapshotVersionFile:   s.Version,
+		snapshotDiscoveryFile: s.Resources,
+		snapshotCRDsFile:      newSnapshotList(s.CRDs),
+	}
+	for resource, objs := range s.Objects {
+		files[snapshotResourcePath(resource)] = newSnapshotList(objs)
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, err := json.Marshal(files[name])
+		if err != nil {
+			return err
+		}
+		if err := archive.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			return err
+		}
+		if _, err := archive.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// WriteFile writes the snapshot to fileName, see Write
+func (s *ClusterSnapshot) WriteFile(fileName string) error {
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if err := s.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ReadClusterSnapshot reads the snapshot written to fileName
+func ReadClusterSnapshot(fileName string) (*ClusterSnapshot, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	snapshot := &ClusterSnapshot{Objects: map[schema.GroupVersionResource][]unstructured.Unstructured{}}
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(archive)
+		if err != nil {
+			return nil, err
+		}
+		if err := snapshot.readFile(header.Name, data); err != nil {
+			return nil, fmt.Errorf("could not read %s of snapshot %s: %v", header.Name, fileName, err)
+		}
+	}
+	if snapshot.Version == nil {
+		return nil, fmt.Errorf("%s is not a cluster snapshot", fileName)
+	}
+	return snapshot, nil
+}
+
+func (s *ClusterSnapshot) readFile(name string, data []byte) error {
+	switch name {
+	case snapshotVersionFile:
+		return json.Unmarshal(data, &s.Version)
+	case snapshotDiscoveryFile:
+		return json.Unmarshal(data, &s.Resources)
+	case snapshotCRDsFile:
+		list := snapshotList{}
+		if err := json.Unmarshal(data, &list); err != nil {
+			return err
+		}
+		s.CRDs = list.Items
+		return nil
+	}
+	resource, ok := parseSnapshotResourcePath(name)
+	if !ok {
+		return nil
+	}
+	list := snapshotList{}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	s.Objects[resource] = list.Items
+	return nil
+}
+
+func newSnapshotList(objs []unstructured.Unstructured) snapshotList {
+	if objs == nil {
+		objs = []unstructured.Unstructured{}
+	}
+	return snapshotList{APIVersion: "v1", Kind: "List", Items: objs}
+}
+
+// snapshotResourcePath returns the path the objects of resource are stored at,
+// resources/<group>/<version>/<resource>.json
+func snapshotResourcePath(resource schema.GroupVersionResource) string {
+	group := resource.Group
+	if len(group) == 0 {
+		group = snapshotCoreGroup
+	}
+	return path.Join(snapshotResourcesDir, group, resource.Version, resource.Resource+".json")
+}
+
+func parseSnapshotResourcePath(name string) (schema.GroupVersionResource, bool) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 4 || parts[0] != snapshotResourcesDir || path.Ext(name) != ".json" {
+		return schema.GroupVersionResource{}, false
+	}
+	group := parts[1]
+	if group == snapshotCoreGroup {
+		group = ""
+	}
+	return schema.GroupVersionResource{Group: group, Version: parts[2], Resource: strings.TrimSuffix(parts[3], ".json")}, true
+}
+
+// redactSecret clears the values of the data of a secret, including those of
+// its last applied configuration, so that snapshots can be shared
+func redactSecret(obj *unstructured.Unstructured) {
+	if obj.GetKind() != "Secret" || obj.GroupVersionKind().Group != "" {
+		return
+	}
+	redactSecretData(obj.Object)
+	annotations := obj.GetAnnotations()
+	lastApplied, ok := annotations[lastAppliedConfigAnnotation]
+	if !ok {
+		return
+	}
+	applied := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(lastApplied), &applied); err != nil {
+		delete(annotations, lastAppliedConfigAnnotation)
+	} else {
+		redactSecretData(applied)
+		redacted, _ := json.Marshal(applied)
+		annotations[lastAppliedConfigAnnotation] = string(redacted)
+	}
+	obj.SetAnnotations(annotations)
+}
+
+func redactSecretData(secret map[string]interface{}) {
+	for _, field := range []string{"data", "stringData"} {
+		data, ok := secret[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key := range data {
+			data[key] = ""
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+var (
+	deploymentsResource = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	snapshotGVKs        = []schema.GroupVersionKind{
+		{Group: "apps", Version: "v1", Kind: "deployment"},
+		{Version: "v1", Kind: "secret"},
+		{Version: "v1", Kind: "binding"},
+		{Group: "batch", Version: "v1", Kind: "job"},
+	}
+)
+
+func testObject(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": apiVersion, "kind": kind}}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func testSnapshotCluster() *Cluster {
+	secret := testObject("v1", "Secret", "apps", "token")
+	secret.Object["data"] = map[string]interface{}{"token": "c2VjcmV0"}
+	secret.SetAnnotations(map[string]string{lastAppliedConfigAnnotation: `{"apiVersion":"v1","kind":"Secret","stringData":{"token":"secret"}}`})
+	clientset := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			deploymentsResource: "DeploymentList",
+			secretsResource:     "SecretList",
+			{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}: "CustomResourceDefinitionList",
+		},
+		testObject("apps/v1", "Deployment", "apps", "web"),
+		testObject("apps/v1", "Deployment", "kube-system", "dns"),
+		secret)
+	disco := &fakediscovery.FakeDiscovery{
+		Fake: &clienttesting.Fake{Resources: []*v1.APIResourceList{
+			{GroupVersion: "v1", APIResources: []v1.APIResource{
+				{Name: "secrets", Kind: "Secret", Namespaced: true},
+				{Name: "bindings", Kind: "Binding", Namespaced: true},
+			}},
+			{GroupVersion: "apps/v1", APIResources: []v1.APIResource{
+				{Name: "deployments", Kind: "Deployment", Namespaced: true},
+				{Name: "deployments/scale", Kind: "Scale", Namespaced: true},
+			}},
+		}},
+		FakedServerVersion: &version.Info{Major: "1", Minor: "21"},
+	}
+	return &Cluster{clientset: clientset, disco: disco}
+}
+
+func objectNames(objs []unstructured.Unstructured) []string {
+	var names []string
+	for _, obj := range objs {
+		names = append(names, obj.GetNamespace()+"/"+obj.GetName())
+	}
+	return names
+}
+
+func TestClusterSnapshot(t *testing.T) {
+	cluster := testSnapshotCluster()
+	snapshot, err := cluster.Snapshot(snapshotGVKs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileName := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	if err := snapshot.WriteFile(fileName); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadClusterSnapshot(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := read.ServerVersion(); got != "1.21" || err != nil {
+		t.Errorf("ServerVersion() = %v, %v, want 1.21", got, err)
+	}
+	for _, conf := range []*Config{
+		{},
+		{IgnoreNamespaces: []string{"kube-system"}},
+		{SelectKinds: []string{"secret"}},
+	} {
+		want := objectNames(cluster.FetchK8sObjects(snapshotGVKs, conf))
+		if got := objectNames(read.FetchK8sObjects(snapshotGVKs, conf)); !reflect.DeepEqual(got, want) {
+			t.Errorf("FetchK8sObjects(%v) = %v, want %v as listed from the cluster", conf, got, want)
+		}
+	}
+	secrets := read.Objects[secretsResource]
+	if len(secrets) != 1 {
+		t.Fatalf("Snapshot() secrets = %v", secrets)
+	}
+	if data, _, _ := unstructured.NestedStringMap(secrets[0].Object, "data"); data["token"] != "" {
+		t.Errorf("Snapshot() secret data = %v, want it redacted", data)
+	}
+	if applied := secrets[0].GetAnnotations()[lastAppliedConfigAnnotation]; applied != `{"apiVersion":"v1","kind":"Secret","stringData":{"token":""}}` {
+		t.Errorf("Snapshot() secret last applied configuration = %s, want it redacted", applied)
+	}
+	if _, err := ReadClusterSnapshot(filepath.Join(t.TempDir(), "missing.tar.gz")); err == nil {
+		t.Error("ReadClusterSnapshot() of a missing snapshot should fail")
+	}
+}
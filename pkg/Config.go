@@ -66,6 +66,10 @@ type Config struct {
 	// FileName is the name to be displayed when testing manifests read from stdin
 	FileName string
 
+	// MetricsFile is the path of the metrics of the apiserver saved from its
+	// /metrics endpoint, which are scraped from the cluster if not set
+	MetricsFile string
+
 	// OutputFormat is the name of the output formatter which will be used when
 	// reporting results to the user.
 	OutputFormat string
@@ -98,6 +102,29 @@ type Config struct {
 
 	// IgnoreNullErrors is the flag to ignore null value errors
 	IgnoreNullErrors 		  bool
+
+	// IgnoreErrorClasses is the list of error categories or codes to be ignored,
+	// e.g. null-value or KDD105
+	IgnoreErrorClasses []string
+
+	// RulesFile is the path of the file with user defined deprecation and policy rules
+	RulesFile string
+
+	// Rules are the rules of RulesFile, as loaded by LoadRules
+	Rules *RuleSet
+}
+
+// LoadRules loads the user defined rules of RulesFile, if it is set
+func (c *Config) LoadRules() error {
+	if len(c.RulesFile) == 0 {
+		return nil
+	}
+	rules, err := LoadRules(c.RulesFile)
+	if err != nil {
+		return err
+	}
+	c.Rules = rules
+	return nil
 }
 
 // NewDefaultConfig creates a Config with default values
@@ -106,12 +133,19 @@ func NewDefaultConfig() *Config {
 		DefaultNamespace:        "default",
 		FileName:                "stdin",
 		TargetKubernetesVersion: "master",
+		Strict:                  true,
 	}
 }
 
 // AddKubeaddFlags adds the default flags for kubedd to cmd
 func AddKubeaddFlags(cmd *cobra.Command, config *Config) *cobra.Command {
 	cmd.Flags().StringVarP(&config.FileName, "filename", "f", "stdin", "filename to be displayed when testing manifests read from stdin")
+	return AddValidationFlags(cmd, config)
+}
+
+// AddValidationFlags adds the flags configuring the validation to cmd, for
+// the commands which do not read manifests from files
+func AddValidationFlags(cmd *cobra.Command, config *Config) *cobra.Command {
 	cmd.Flags().StringVarP(&config.TargetSchemaLocation, "target-schema-location", "", "", "TargetSchemaLocation is the base URL of target kubernetes version.")
 	cmd.Flags().StringVarP(&config.SourceSchemaLocation, "source-schema-location", "", "", "SourceSchemaLocation is the base URL of source kubernetes versions.")
 	cmd.Flags().StringVarP(&config.TargetKubernetesVersion, "target-kubernetes-version", "", "1.22", "Version of Kubernetes to migrate to")
@@ -123,9 +157,13 @@ func AddKubeaddFlags(cmd *cobra.Command, config *Config) *cobra.Command {
 	cmd.Flags().StringSliceVarP(&config.IgnoreNamespaces, "ignore-namespaces", "", []string{"kube-system"}, "A comma-separated list of namespaces to be skipped")
 	cmd.Flags().StringSliceVarP(&config.IgnoreKinds, "ignore-kinds", "", []string{"event","CustomResourceDefinition"}, "A comma-separated list of kinds to be skipped")
 	cmd.Flags().StringSliceVarP(&config.SelectKinds, "select-kinds", "", []string{}, "A comma-separated list of kinds to be selected, if left empty all namespaces are selected")
-	cmd.Flags().StringSliceVarP(&config.IgnoreKeysFromDeprecation, "ignore-keys-for-deprecation", "", []string{"metadata*", "status*"}, "A comma-separated list of keys to be ignored for depreciation check")
-	cmd.Flags().StringSliceVarP(&config.IgnoreKeysFromValidation, "ignore-keys-for-validation", "", []string{"status*", "metadata*"}, "A comma-separated list of keys to be ignored for validation check")
+	cmd.Flags().StringSliceVarP(&config.IgnoreKeysFromDeprecation, "ignore-keys-for-deprecation", "", []string{"status*", "metadata/managedFields*"}, "A comma-separated list of keys to be ignored for depreciation check")
+	cmd.Flags().StringSliceVarP(&config.IgnoreKeysFromValidation, "ignore-keys-for-validation", "", []string{"status*", "metadata/creationTimestamp", "metadata/managedFields*"}, "A comma-separated list of keys to be ignored for validation check")
 	cmd.Flags().BoolVar(&config.IgnoreNullErrors, "ignore-null-errors", true, "Ignore null value errors")
+	cmd.Flags().StringSliceVarP(&config.IgnoreErrorClasses, "ignore-error-classes", "", []string{}, "A comma-separated list of error categories or codes to be ignored, e.g. null-value,KDD101")
+	cmd.Flags().BoolVar(&config.Strict, "strict", true, "Reject properties not defined in the schema as kubectl --validate=strict does, if false they are reported as warnings")
+	cmd.Flags().BoolVar(&config.IgnoreMissingSchemas, "ignore-missing-schemas", false, "Report resources without an available schema as unvalidated instead of failing")
+	cmd.Flags().StringVarP(&config.RulesFile, "rules", "", "", "Path of a yaml file with user defined deprecation and policy rules")
 
 	return cmd
 }
@@ -0,0 +1,189 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const objectMetaComponent = "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"
+
+// crdVersion is a version of a CustomResourceDefinition with its schema
+type crdVersion struct {
+	Name               string
+	Served             bool
+	Deprecated         bool
+	DeprecationWarning string
+	Schema             map[string]interface{}
+}
+
+// IsCustomResourceDefinition checks whether object is a CustomResourceDefinition
+func IsCustomResourceDefinition(object map[string]interface{}) bool {
+	apiVersion, _ := object["apiVersion"].(string)
+	kind, _ := object["kind"].(string)
+	return kind == "CustomResourceDefinition" && strings.HasPrefix(apiVersion, "apiextensions.k8s.io/")
+}
+
+// RegisterCRD adds the schemas of the served versions of the
+// CustomResourceDefinition crd to every loaded kubernetes version, so that
+// its custom resources are validated the same way the built in kinds are
+func (k *kubeCheckerImpl) RegisterCRD(crd map[string]interface{}) error {
+	for _, ks := range k.versionMap {
+		if err := ks.addCRD(crd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ks *kubeSpec) addCRD(crd map[string]interface{}) error {
+	group, _, _ := unstructured.NestedString(crd, "spec", "group")
+	kind, _, _ := unstructured.NestedString(crd, "spec", "names", "kind")
+	plural, _, _ := unstructured.NestedString(crd, "spec", "names", "plural")
+	scope, _, _ := unstructured.NestedString(crd, "spec", "scope")
+	if len(group) == 0 || len(kind) == 0 || len(plural) == 0 {
+		return fmt.Errorf("invalid custom resource definition, missing group, kind or plural")
+	}
+	versions := crdVersions(crd)
+	if len(versions) == 0 {
+		return fmt.Errorf("custom resource definition %s.%s has no versions", plural, group)
+	}
+	kindKey := strings.ToLower(kind)
+	for _, version := range versions {
+		component := crdComponentName(group, version.Name, kind)
+		schema, err := ks.crdSchema(group, version, kind)
+		if err != nil {
+			return fmt.Errorf("custom resource definition %s.%s version %s: %v", plural, group, version.Name, err)
+		}
+		ks.T.Components.Schemas[component] = &openapi3.SchemaRef{Value: schema}
+		ks.schemaNames[schema] = component
+		ki := &KindInfo{
+			Version:      version.Name,
+			Group:        group,
+			ComponentKey: component,
+			IsGA:         getVersionType(version.Name) == gaVersion,
+			Namespaced:   scope != "Cluster",
+		}
+		if version.Served {
+			ki.RestPath = fmt.Sprintf("/apis/%s/%s/%s", group, version.Name, plural)
+			if ki.Namespaced {
+				ki.RestPath = fmt.Sprintf("/apis/%s/%s/namespaces/{namespace}/%s", group, version.Name, plural)
+			}
+		}
+		kindInfos := ks.kindInfoMap[kindKey][:0:0]
+		for _, existing := range ks.kindInfoMap[kindKey] {
+			if existing.ComponentKey != component {
+				kindInfos = append(kindInfos, existing)
+			}
+		}
+		ks.kindInfoMap[kindKey] = append(kindInfos, ki)
+	}
+	gvs := ks.kindInfoMap[kindKey]
+	sort.Slice(gvs, func(i, j int) bool {
+		return compareVersion(gvs[i].Version, gvs[j].Version)
+	})
+	return nil
+}
+
+// crdVersions reads the versions of both apiextensions.k8s.io/v1 and v1beta1
+// definitions, the latter may have a single schema for all the versions
+func crdVersions(crd map[string]interface{}) []crdVersion {
+	sharedSchema, _, _ := unstructured.NestedMap(crd, "spec", "validation", "openAPIV3Schema")
+	var versions []crdVersion
+	items, _, _ := unstructured.NestedSlice(crd, "spec", "versions")
+	for _, item := range items {
+		v, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		version := crdVersion{Served: true, Schema: sharedSchema}
+		version.Name, _, _ = unstructured.NestedString(v, "name")
+		if served, found, _ := unstructured.NestedBool(v, "served"); found {
+			version.Served = served
+		}
+		version.Deprecated, _, _ = unstructured.NestedBool(v, "deprecated")
+		version.DeprecationWarning, _, _ = unstructured.NestedString(v, "deprecationWarning")
+		if schema, found, _ := unstructured.NestedMap(v, "schema", "openAPIV3Schema"); found {
+			version.Schema = schema
+		}
+		if len(version.Name) > 0 {
+			versions = append(versions, version)
+		}
+	}
+	if name, found, _ := unstructured.NestedString(crd, "spec", "version"); found && len(versions) == 0 {
+		versions = append(versions, crdVersion{Name: name, Served: true, Schema: sharedSchema})
+	}
+	return versions
+}
+
+// crdSchema converts the openAPIV3Schema of a version to the schema of a
+// component, with the fields common to all kinds and the group version kind
+// extension the built in components have
+func (ks *kubeSpec) crdSchema(group string, version crdVersion, kind string) (*openapi3.Schema, error) {
+	schema := &openapi3.Schema{Type: "object"}
+	if version.Schema != nil {
+		data, err := json.Marshal(version.Schema)
+		if err != nil {
+			return nil, err
+		}
+		if err := schema.UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+	}
+	if schema.Properties == nil {
+		schema.Properties = map[string]*openapi3.SchemaRef{}
+	}
+	schema.Properties["apiVersion"] = &openapi3.SchemaRef{Value: openapi3.NewStringSchema()}
+	schema.Properties["kind"] = &openapi3.SchemaRef{Value: openapi3.NewStringSchema()}
+	if metadata, ok := ks.T.Components.Schemas[objectMetaComponent]; ok {
+		schema.Properties["metadata"] = &openapi3.SchemaRef{Ref: "#/components/schemas/" + objectMetaComponent, Value: metadata.Value}
+	}
+	disallowUnknownFields(schema)
+	gvk, err := json.Marshal([]map[string]string{{"group": group, "version": version.Name, "kind": kind}})
+	if err != nil {
+		return nil, err
+	}
+	if schema.Extensions == nil {
+		schema.Extensions = map[string]interface{}{}
+	}
+	schema.Extensions["x-kubernetes-group-version-kind"] = json.RawMessage(gvk)
+	if version.Deprecated {
+		schema.Deprecated = true
+		schema.Description = version.DeprecationWarning
+		if len(schema.Description) == 0 {
+			schema.Description = fmt.Sprintf("%s/%s %s is deprecated", group, version.Name, kind)
+		}
+	}
+	return schema, nil
+}
+
+// crdComponentName names the schema of a custom resource the way the
+// apiserver publishes it, e.g. io.example.v1.Widget for example.io/v1 Widget
+func crdComponentName(group, version, kind string) string {
+	parts := strings.Split(group, ".")
+	for left, right := 0, len(parts)-1; left < right; left, right = left+1, right-1 {
+		parts[left], parts[right] = parts[right], parts[left]
+	}
+	return fmt.Sprintf("%s.%s.%s", strings.Join(parts, "."), version, kind)
+}
@@ -0,0 +1,190 @@
+package pkg
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+const widgetCRD = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.io
+spec:
+  group: example.io
+  scope: Namespaced
+  names:
+    kind: Widget
+    plural: widgets
+  versions:
+  - name: v1alpha1
+    served: true
+    deprecated: true
+    deprecationWarning: example.io/v1alpha1 Widget is deprecated, use example.io/v1
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              size:
+                type: string
+  - name: v1
+    served: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            x-kubernetes-validations:
+            - rule: self.replicas <= self.maxReplicas
+              message: replicas must not exceed maxReplicas
+              fieldPath: .replicas
+            - rule: self.mode == oldSelf.mode
+              message: mode is immutable
+            - rule: "!has(self.mode) || self.mode in ['fast', 'slow']"
+              messageExpression: "'unknown mode ' + self.mode"
+            - rule: "!has(self.ports) || self.ports.map(p, p.port).isSorted()"
+              message: ports must be sorted
+            - rule: "!has(self.ports) || self.ports.map(p, p.port).sum() <= 65535"
+              message: ports must not add up beyond 65535
+            - rule: "!has(self.mode) || self.mode.find('^[a-z]+$') == self.mode"
+              message: mode must be lower case
+            - rule: "!has(self.endpoint) || url(self.endpoint).getScheme() == 'https'"
+              message: endpoint must be https
+            properties:
+              replicas:
+                type: integer
+              maxReplicas:
+                type: integer
+              mode:
+                type: string
+              endpoint:
+                type: string
+              ports:
+                type: array
+                items:
+                  type: object
+                  x-kubernetes-validations:
+                  - rule: self.port > 1024
+                  properties:
+                    port:
+                      type: integer
+`
+
+func loadWidgetSpec(t *testing.T) *kubeSpec {
+	ks := loadTestSpec(t)
+	crd := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(widgetCRD), &crd); err != nil {
+		t.Fatal(err)
+	}
+	if !IsCustomResourceDefinition(crd) {
+		t.Fatal("IsCustomResourceDefinition() = false, want true")
+	}
+	if err := ks.addCRD(crd); err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+func TestKubeSpec_addCRD(t *testing.T) {
+	ks := loadWidgetSpec(t)
+	if !ks.IsVersionSupported("example.io/v1", "Widget") || !ks.IsVersionSupported("example.io/v1alpha1", "Widget") {
+		t.Errorf("IsVersionSupported() = false for served versions of Widget")
+	}
+	var versions []string
+	for _, ki := range ks.kindInfoMap["widget"] {
+		versions = append(versions, ki.Version)
+		if ki.RestPath != "/apis/example.io/"+ki.Version+"/namespaces/{namespace}/widgets" {
+			t.Errorf("RestPath = %s", ki.RestPath)
+		}
+	}
+	if !reflect.DeepEqual(versions, []string{"v1alpha1", "v1"}) {
+		t.Errorf("versions = %v, want [v1alpha1 v1]", versions)
+	}
+
+	object := map[string]interface{}{
+		"apiVersion": "example.io/v1alpha1",
+		"kind":       "Widget",
+		"metadata":   map[string]interface{}{"name": "small"},
+		"spec":       map[string]interface{}{"size": "small"},
+	}
+	result, err := ks.ValidateObject(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.LatestAPIVersion != "example.io/v1" {
+		t.Errorf("LatestAPIVersion = %s, want example.io/v1", result.LatestAPIVersion)
+	}
+	if len(result.DeprecationForOriginal) != 1 || len(result.DeprecationForOriginal[0].JSONPointer()) != 0 {
+		t.Errorf("DeprecationForOriginal = %v, want the deprecated version", result.DeprecationForOriginal)
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	ks := loadWidgetSpec(t)
+	tests := []struct {
+		name string
+		spec string
+		want []string
+	}{
+		{
+			name: "valid",
+			spec: `{"replicas": 2, "maxReplicas": 3, "mode": "fast", "endpoint": "https://example.io", "ports": [{"port": 8080}, {"port": 8443}]}`,
+		},
+		{
+			name: "invalid",
+			spec: `{"replicas": 4, "maxReplicas": 3, "mode": "turbo", "endpoint": "http://example.io", "ports": [{"port": 8080}, {"port": 80}]}`,
+			want: []string{
+				"spec/ports/1: failed rule: self.port > 1024",
+				"spec/replicas: replicas must not exceed maxReplicas",
+				"spec: ports must be sorted",
+				"spec: unknown mode turbo",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := make(map[string]interface{})
+			if err := yaml.Unmarshal([]byte(tt.spec), &spec); err != nil {
+				t.Fatal(err)
+			}
+			object := map[string]interface{}{
+				"apiVersion": "example.io/v1",
+				"kind":       "Widget",
+				"metadata":   map[string]interface{}{"name": "big"},
+				"spec":       spec,
+			}
+			result, err := ks.ValidateObject(object)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			warnings := 0
+			for _, e := range result.ErrorsForOriginal {
+				// url() is not provided, the rule is reported as a warning
+				if e.Severity == SeverityWarning {
+					if !strings.Contains(e.Reason, "could not be compiled") {
+						t.Errorf("warning = %q, want the rule which could not be compiled", e.Reason)
+					}
+					warnings++
+					continue
+				}
+				got = append(got, strings.Join(e.JSONPointer(), "/")+": "+e.Reason)
+			}
+			if warnings != 1 {
+				t.Errorf("got %d warnings, want the url rule", warnings)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ErrorsForOriginal = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
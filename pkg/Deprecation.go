@@ -0,0 +1,213 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	defaultDeprecationMessage = "field is deprecated"
+	deprecationSchemaField    = "deprecated"
+)
+
+// deprecationExtensions are the schema extensions which explicitly mark a
+// schema as deprecated, they take precedence over the description
+var deprecationExtensions = []string{"x-kubernetes-deprecated", "x-deprecated"}
+
+var (
+	sentenceSeparator = regexp.MustCompile(`[.;]\s+|\n+`)
+	notDeprecated     = regexp.MustCompile(`\bnot\s+(yet\s+)?(deprecated|depreciated)\b|\bnon-deprecated\b|\bundeprecated\b`)
+	leadingDeprecated = regexp.MustCompile(`^\W*(deprecated|depreciated)\b`)
+	thisIsDeprecated  = regexp.MustCompile(`^(this|the)\s+(field|value|property|type|api|resource|option|setting)\s+(is|has\s+been|was)\s+(now\s+)?(deprecated|depreciated)\b`)
+	subjectDeprecated = regexp.MustCompile(`^([\w.\-]+)\s+(is|has\s+been|was)\s+(a\s+|now\s+)?(deprecated|depreciated)\b`)
+	replacementHints  = []*regexp.Regexp{
+		regexp.MustCompile("(?i)\\buse\\s+(?:the\\s+)?[`\"']?([A-Za-z][\\w.\\-/]*)[`\"']?(?:\\s+field)?\\s+instead\\b"),
+		regexp.MustCompile("(?i)\\bin\\s+favou?r\\s+of\\s+[`\"']?([A-Za-z][\\w.\\-/]*)"),
+		regexp.MustCompile("(?i)\\breplaced\\s+by\\s+[`\"']?([A-Za-z][\\w.\\-/]*)"),
+		regexp.MustCompile("(?i)\\bdeprecated\\s+by\\s+[`\"']?([A-Za-z][\\w.\\-/]*)"),
+	}
+)
+
+// DeprecationInfo is the outcome of classifying a schema for deprecation
+type DeprecationInfo struct {
+	Deprecated  bool
+	Message     string
+	Replacement string
+}
+
+// DeprecationOverride forces the deprecation status of a schema irrespective
+// of what its description or extensions say, from release DeprecatedIn on if
+// it is set
+type DeprecationOverride struct {
+	Deprecated   bool
+	Message      string
+	Replacement  string
+	DeprecatedIn string
+}
+
+// deprecationOverrides is the curated list of fields whose deprecation status
+// cannot be derived from the openapi spec. Keys are either a component name,
+// e.g. io.k8s.api.core.v1.PodSpec, or a component name followed by a property,
+// e.g. io.k8s.api.core.v1.PodSpec.serviceAccount
+var deprecationOverrides = map[string]DeprecationOverride{
+	"io.k8s.api.core.v1.PodSpec.serviceAccount": {
+		Deprecated:  true,
+		Message:     "DeprecatedServiceAccount is a deprecated alias for ServiceAccountName",
+		Replacement: "serviceAccountName",
+	},
+	"io.k8s.api.core.v1.ServiceSpec.loadBalancerIP": {
+		Deprecated:   true,
+		Message:      "loadBalancerIP is deprecated since 1.24, its support is implementation specific, use the annotations of the load balancer implementation instead",
+		DeprecatedIn: "1.24",
+	},
+	"io.k8s.api.core.v1.NodeSpec.externalID": {
+		Deprecated: true,
+		Message:    "externalID is deprecated and not set by all kubelets",
+	},
+}
+
+// appliesTo checks whether the override holds for kubernetes releaseVersion
+func (o DeprecationOverride) appliesTo(releaseVersion string) bool {
+	return len(o.DeprecatedIn) == 0 || releaseAtLeast(releaseVersion, o.DeprecatedIn)
+}
+
+// RegisterDeprecationOverride is used to add or replace an entry in the
+// curated deprecation override list
+func RegisterDeprecationOverride(key string, override DeprecationOverride) {
+	deprecationOverrides[key] = override
+}
+
+// classifyDeprecation decides whether the schema of property in component is
+// deprecated in kubernetes releaseVersion, property is empty when classifying
+// the component itself
+func classifyDeprecation(component, property string, schema *openapi3.Schema, releaseVersion string) DeprecationInfo {
+	if schema == nil {
+		return DeprecationInfo{}
+	}
+	key := component
+	if len(property) > 0 {
+		key = component + "." + property
+	}
+	if override, ok := deprecationOverrides[key]; ok && len(component) > 0 && override.appliesTo(releaseVersion) {
+		info := DeprecationInfo{Deprecated: override.Deprecated, Message: override.Message, Replacement: override.Replacement}
+		if info.Deprecated && len(info.Message) == 0 {
+			info.Message = schema.Description
+		}
+		return info
+	}
+	if info, ok := classifyExtension(schema); ok {
+		return info
+	}
+	subject := property
+	if len(subject) == 0 {
+		parts := strings.Split(component, ".")
+		subject = parts[len(parts)-1]
+	}
+	return classifyDescription(subject, schema.Description)
+}
+
+// classifyExtension reads the explicit deprecation markers of a schema, the
+// boolean reports whether any marker was present
+func classifyExtension(schema *openapi3.Schema) (DeprecationInfo, bool) {
+	for _, name := range deprecationExtensions {
+		raw, ok := schema.Extensions[name].(json.RawMessage)
+		if !ok {
+			continue
+		}
+		var flag bool
+		if err := json.Unmarshal(raw, &flag); err == nil {
+			if !flag {
+				return DeprecationInfo{}, true
+			}
+			return DeprecationInfo{Deprecated: true, Message: messageOrDefault(schema.Description), Replacement: findReplacement(schema.Description)}, true
+		}
+		var message string
+		if err := json.Unmarshal(raw, &message); err == nil {
+			return DeprecationInfo{Deprecated: true, Message: messageOrDefault(message), Replacement: findReplacement(message)}, true
+		}
+		var detail struct {
+			Message     string `json:"message"`
+			Replacement string `json:"replacement"`
+		}
+		if err := json.Unmarshal(raw, &detail); err == nil {
+			info := DeprecationInfo{Deprecated: true, Message: messageOrDefault(detail.Message), Replacement: detail.Replacement}
+			if len(info.Replacement) == 0 {
+				info.Replacement = findReplacement(detail.Message)
+			}
+			return info, true
+		}
+	}
+	if schema.Deprecated {
+		return DeprecationInfo{Deprecated: true, Message: messageOrDefault(schema.Description), Replacement: findReplacement(schema.Description)}, true
+	}
+	return DeprecationInfo{}, false
+}
+
+// classifyDescription parses the usual kubernetes phrasings of deprecation,
+// e.g. "Deprecated: use X instead" or "DEPRECATED - This field ...". Sentences
+// which deprecate some other field than subject are not taken into account
+func classifyDescription(subject, description string) DeprecationInfo {
+	for _, sentence := range sentenceSeparator.Split(description, -1) {
+		sentence = strings.ToLower(strings.TrimSpace(sentence))
+		if len(sentence) == 0 || notDeprecated.MatchString(sentence) {
+			continue
+		}
+		deprecated := leadingDeprecated.MatchString(sentence) || thisIsDeprecated.MatchString(sentence)
+		if !deprecated {
+			if match := subjectDeprecated.FindStringSubmatch(sentence); match != nil {
+				deprecated = isSubject(match[1], subject)
+			}
+		}
+		if deprecated {
+			return DeprecationInfo{Deprecated: true, Message: description, Replacement: findReplacement(description)}
+		}
+	}
+	return DeprecationInfo{}
+}
+
+// isSubject checks whether word refers to the field being classified, the
+// kubernetes descriptions usually start with the go name of the field
+func isSubject(word, subject string) bool {
+	if len(subject) == 0 {
+		return false
+	}
+	word = strings.ToLower(word)
+	subject = strings.ToLower(subject)
+	return word == subject || word == "deprecated"+subject || word == "it"
+}
+
+func findReplacement(description string) string {
+	for _, hint := range replacementHints {
+		if match := hint.FindStringSubmatch(description); match != nil {
+			return strings.TrimRight(match[1], ".,")
+		}
+	}
+	return ""
+}
+
+func messageOrDefault(message string) string {
+	if len(strings.TrimSpace(message)) == 0 {
+		return defaultDeprecationMessage
+	}
+	return message
+}
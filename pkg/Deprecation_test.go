@@ -0,0 +1,213 @@
+package pkg
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func Test_classifyDescription(t *testing.T) {
+	type args struct {
+		subject     string
+		description string
+	}
+	tests := []struct {
+		name            string
+		args            args
+		wantDeprecated  bool
+		wantReplacement string
+	}{
+		{
+			name: "deprecated with replacement",
+			args: args{
+				subject:     "serviceAccount",
+				description: "DeprecatedServiceAccount is a depreciated alias for ServiceAccountName. Deprecated: Use serviceAccountName instead.",
+			},
+			wantDeprecated:  true,
+			wantReplacement: "serviceAccountName",
+		},
+		{
+			name: "upper case deprecated with dash",
+			args: args{
+				subject:     "Deployment",
+				description: "DEPRECATED - This group version of Deployment is deprecated by apps/v1beta2/Deployment. See the release notes for more information.",
+			},
+			wantDeprecated:  true,
+			wantReplacement: "apps/v1beta2/Deployment",
+		},
+		{
+			name: "deprecated sentence in the middle",
+			args: args{
+				subject:     "rollbackTo",
+				description: "The config this deployment is rolling back to. Will be cleared after rollback is done. DEPRECATED.",
+			},
+			wantDeprecated: true,
+		},
+		{
+			name: "this field is deprecated",
+			args: args{
+				subject:     "topologyKeys",
+				description: "topologyKeys is a preference-order list of topology keys. This field is deprecated and will be removed in a future version.",
+			},
+			wantDeprecated: true,
+		},
+		{
+			name: "not deprecated",
+			args: args{
+				subject:     "port",
+				description: "The port on which the service is exposed. This field is not deprecated.",
+			},
+			wantDeprecated: false,
+		},
+		{
+			name: "deprecated sibling",
+			args: args{
+				subject:     "serviceAccountName",
+				description: "ServiceAccountName is the name of the ServiceAccount to use to run this pod. serviceAccount is deprecated, prefer this field.",
+			},
+			wantDeprecated: false,
+		},
+		{
+			name: "no mention",
+			args: args{
+				subject:     "replicas",
+				description: "Number of desired pods. This is a pointer to distinguish between explicit zero and not specified.",
+			},
+			wantDeprecated: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyDescription(tt.args.subject, tt.args.description)
+			if got.Deprecated != tt.wantDeprecated {
+				t.Errorf("classifyDescription() deprecated = %v, want %v", got.Deprecated, tt.wantDeprecated)
+			}
+			if got.Replacement != tt.wantReplacement {
+				t.Errorf("classifyDescription() replacement = %v, want %v", got.Replacement, tt.wantReplacement)
+			}
+		})
+	}
+}
+
+func Test_classifyDeprecation(t *testing.T) {
+	tests := []struct {
+		name            string
+		component       string
+		property        string
+		schema          *openapi3.Schema
+		releaseVersion  string
+		wantDeprecated  bool
+		wantReplacement string
+	}{
+		{
+			name:     "extension flag",
+			property: "legacy",
+			schema: &openapi3.Schema{ExtensionProps: openapi3.ExtensionProps{Extensions: map[string]interface{}{
+				"x-kubernetes-deprecated": json.RawMessage(`true`),
+			}}, Description: "Legacy setting, use modern instead"},
+			wantDeprecated:  true,
+			wantReplacement: "modern",
+		},
+		{
+			name:     "extension message",
+			property: "legacy",
+			schema: &openapi3.Schema{ExtensionProps: openapi3.ExtensionProps{Extensions: map[string]interface{}{
+				"x-kubernetes-deprecated": json.RawMessage(`"replaced by spec.modern"`),
+			}}},
+			wantDeprecated:  true,
+			wantReplacement: "spec.modern",
+		},
+		{
+			name:     "extension false suppresses description",
+			property: "legacy",
+			schema: &openapi3.Schema{ExtensionProps: openapi3.ExtensionProps{Extensions: map[string]interface{}{
+				"x-kubernetes-deprecated": json.RawMessage(`false`),
+			}}, Description: "Deprecated: do not use"},
+			wantDeprecated: false,
+		},
+		{
+			name:           "override adds entry",
+			component:      "io.k8s.api.core.v1.ServiceSpec",
+			property:       "loadBalancerIP",
+			schema:         &openapi3.Schema{Description: "Only applies to Service Type: LoadBalancer."},
+			releaseVersion: "1.24",
+			wantDeprecated: true,
+		},
+		{
+			name:           "override before the release it is deprecated in",
+			component:      "io.k8s.api.core.v1.ServiceSpec",
+			property:       "loadBalancerIP",
+			schema:         &openapi3.Schema{Description: "Only applies to Service Type: LoadBalancer."},
+			releaseVersion: "1.22",
+			wantDeprecated: false,
+		},
+		{
+			name:            "override without release",
+			component:       "io.k8s.api.core.v1.PodSpec",
+			property:        "serviceAccount",
+			schema:          &openapi3.Schema{Description: "DeprecatedServiceAccount is a depreciated alias for ServiceAccountName."},
+			releaseVersion:  "1.22",
+			wantDeprecated:  true,
+			wantReplacement: "serviceAccountName",
+		},
+		{
+			name:           "deprecated meta fields",
+			component:      "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta",
+			property:       "selfLink",
+			schema:         &openapi3.Schema{Description: "SelfLink is a URL representing this object.\n\nDEPRECATED Kubernetes will stop propagating this field in 1.20 release."},
+			releaseVersion: "1.22",
+			wantDeprecated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyDeprecation(tt.component, tt.property, tt.schema, tt.releaseVersion)
+			if got.Deprecated != tt.wantDeprecated {
+				t.Errorf("classifyDeprecation() deprecated = %v, want %v", got.Deprecated, tt.wantDeprecated)
+			}
+			if got.Replacement != tt.wantReplacement {
+				t.Errorf("classifyDeprecation() replacement = %v, want %v", got.Replacement, tt.wantReplacement)
+			}
+		})
+	}
+}
+
+func TestRegisterDeprecationOverride(t *testing.T) {
+	key := "io.k8s.api.example.v1.WidgetSpec.legacy"
+	RegisterDeprecationOverride(key, DeprecationOverride{Deprecated: false})
+	defer delete(deprecationOverrides, key)
+
+	schema := &openapi3.Schema{Description: "Deprecated: legacy is replaced by modern"}
+	if got := classifyDeprecation("io.k8s.api.example.v1.WidgetSpec", "legacy", schema, "1.22"); got.Deprecated {
+		t.Errorf("classifyDeprecation() deprecated = %v, want false for an override which suppresses the entry", got.Deprecated)
+	}
+	RegisterDeprecationOverride("io.k8s.api.example.v1.WidgetSpec", DeprecationOverride{Deprecated: true})
+	defer delete(deprecationOverrides, "io.k8s.api.example.v1.WidgetSpec")
+	if got := classifyDeprecation("io.k8s.api.example.v1.WidgetSpec", "", nil, "1.22"); got.Deprecated {
+		t.Errorf("classifyDeprecation() deprecated = %v, want false for a missing schema", got.Deprecated)
+	}
+}
+
+func TestVisitJSON(t *testing.T) {
+	podSpec := &openapi3.Schema{
+		Type: "object",
+		Properties: map[string]*openapi3.SchemaRef{
+			"serviceAccount":     {Value: &openapi3.Schema{Type: "string", Description: "Deprecated: Use serviceAccountName instead."}},
+			"serviceAccountName": {Value: &openapi3.Schema{Type: "string", Description: "serviceAccount is deprecated, use this field."}},
+		},
+	}
+	object := map[string]interface{}{"serviceAccount": "default", "serviceAccountName": "default"}
+	errs := VisitJSON(podSpec, object, SchemaSettings{MultiError: true})
+	if len(errs) != 1 {
+		t.Fatalf("VisitJSON() got %d errors, want 1", len(errs))
+	}
+	se := errs[0].(*SchemaError)
+	if got := strings.Join(se.JSONPointer(), "/"); got != "serviceAccount" {
+		t.Errorf("VisitJSON() path = %v, want serviceAccount", got)
+	}
+	if se.Replacement != "serviceAccountName" {
+		t.Errorf("VisitJSON() replacement = %v, want serviceAccountName", se.Replacement)
+	}
+}
@@ -0,0 +1,227 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ErrorCategory classifies the validation errors and deprecations, so that
+// they can be filtered and consumed without parsing their reasons
+type ErrorCategory string
+
+const (
+	CategoryParseError      ErrorCategory = "parse-error"
+	CategoryKubeVersion     ErrorCategory = "kube-version"
+	CategoryUnknownField    ErrorCategory = "unknown-field"
+	CategoryTypeMismatch    ErrorCategory = "type-mismatch"
+	CategoryRequiredMissing ErrorCategory = "required-missing"
+	CategoryEnumViolation   ErrorCategory = "enum-violation"
+	CategoryNullValue       ErrorCategory = "null-value"
+	CategoryFormatViolation ErrorCategory = "format-violation"
+	CategoryPattern         ErrorCategory = "pattern"
+	CategoryMinMax          ErrorCategory = "min-max"
+	CategoryDuplicateValue  ErrorCategory = "duplicate-value"
+	CategoryInvalidValue    ErrorCategory = "invalid-value"
+	CategoryValidationRule  ErrorCategory = "validation-rule"
+	CategoryDeprecatedField ErrorCategory = "deprecated-field"
+	CategoryDeprecatedLabel ErrorCategory = "deprecated-label"
+	CategoryStaleSelector   ErrorCategory = "stale-selector"
+	CategoryPolicyRule      ErrorCategory = "policy-rule"
+)
+
+// categoryCodes are the stable codes of the categories, codes are never
+// reused once published
+var categoryCodes = map[ErrorCategory]string{
+	CategoryParseError:      "KDD001",
+	CategoryKubeVersion:     "KDD002",
+	CategoryUnknownField:    "KDD101",
+	CategoryTypeMismatch:    "KDD102",
+	CategoryRequiredMissing: "KDD103",
+	CategoryEnumViolation:   "KDD104",
+	CategoryNullValue:       "KDD105",
+	CategoryFormatViolation: "KDD106",
+	CategoryPattern:         "KDD107",
+	CategoryMinMax:          "KDD108",
+	CategoryDuplicateValue:  "KDD109",
+	CategoryInvalidValue:    "KDD110",
+	CategoryValidationRule:  "KDD111",
+	CategoryDeprecatedField: "KDD201",
+	CategoryDeprecatedLabel: "KDD202",
+	CategoryStaleSelector:   "KDD203",
+	CategoryPolicyRule:      "KDD301",
+}
+
+// schemaFieldCategories maps the schema keyword or extension which raised an
+// error to its category
+var schemaFieldCategories = map[string]ErrorCategory{
+	"properties":              CategoryUnknownField,
+	"additionalProperties":    CategoryUnknownField,
+	"type":                    CategoryTypeMismatch,
+	"required":                CategoryRequiredMissing,
+	"enum":                    CategoryEnumViolation,
+	"nullable":                CategoryNullValue,
+	"format":                  CategoryFormatViolation,
+	"pattern":                 CategoryPattern,
+	"minimum":                 CategoryMinMax,
+	"maximum":                 CategoryMinMax,
+	"exclusiveMinimum":        CategoryMinMax,
+	"exclusiveMaximum":        CategoryMinMax,
+	"minLength":               CategoryMinMax,
+	"maxLength":               CategoryMinMax,
+	"minItems":                CategoryMinMax,
+	"maxItems":                CategoryMinMax,
+	"minProperties":           CategoryMinMax,
+	"maxProperties":           CategoryMinMax,
+	"uniqueItems":             CategoryDuplicateValue,
+	listTypeExtension:         CategoryDuplicateValue,
+	intOrStringExtension:      CategoryTypeMismatch,
+	embeddedResourceExtension: CategoryRequiredMissing,
+	validationRulesExtension:  CategoryValidationRule,
+	wellKnownLabelSchemaField: CategoryDeprecatedLabel,
+	ruleSchemaField:           CategoryPolicyRule,
+	deprecationSchemaField:    CategoryDeprecatedField,
+	selectorSchemaField:       CategoryStaleSelector,
+}
+
+// Code returns the stable code of the category
+func (c ErrorCategory) Code() string {
+	return categoryCodes[c]
+}
+
+var quotedProperty = regexp.MustCompile(`property "([^"]*)"`)
+
+// IsErrorClass checks whether err belongs to class, which is either the
+// category or the code of the error
+func IsErrorClass(err *SchemaError, class string) bool {
+	category := err.Category
+	if len(category) == 0 {
+		category = schemaFieldCategories[err.SchemaField]
+	}
+	return strings.EqualFold(string(category), class) || strings.EqualFold(categoryCodes[category], class)
+}
+
+// IsAnyErrorClass checks whether err belongs to one of classes
+func IsAnyErrorClass(err *SchemaError, classes []string) bool {
+	for _, class := range classes {
+		if IsErrorClass(err, strings.TrimSpace(class)) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifySchemaError sets the category and code of err, along with the
+// expected and actual values where the schema tells them. Errors which are
+// already classified keep their category
+func classifySchemaError(err *SchemaError) *SchemaError {
+	if len(err.Category) == 0 {
+		err.Category = schemaFieldCategories[err.SchemaField]
+		if len(err.Category) == 0 {
+			err.Category = CategoryInvalidValue
+		}
+	}
+	err.Code = categoryCodes[err.Category]
+	if err.Actual == nil {
+		err.Actual = err.Value
+	}
+	schema := err.Schema
+	switch err.SchemaField {
+	case "properties", "required":
+		if match := quotedProperty.FindStringSubmatch(err.Reason); match != nil {
+			if err.SchemaField == "properties" {
+				err.Actual = match[1]
+			} else {
+				err.Expected = match[1]
+				err.Actual = nil
+			}
+		}
+	case "type":
+		if schema != nil {
+			err.Expected = schema.Type
+		}
+		// the openapi validation reports the type of the value as its value
+		if _, ok := err.Value.(string); !ok {
+			err.Actual = jsonType(err.Value)
+		}
+	case "enum":
+		if schema != nil {
+			err.Expected = schema.Enum
+		}
+	case "format":
+		if schema != nil {
+			err.Expected = schema.Format
+		}
+	case "pattern":
+		if schema != nil {
+			err.Expected = schema.Pattern
+		}
+	case "minimum", "exclusiveMinimum":
+		if schema != nil && schema.Min != nil {
+			err.Expected = *schema.Min
+		}
+	case "maximum", "exclusiveMaximum":
+		if schema != nil && schema.Max != nil {
+			err.Expected = *schema.Max
+		}
+	case "minLength":
+		if schema != nil {
+			err.Expected = schema.MinLength
+		}
+	case "maxLength":
+		if schema != nil && schema.MaxLength != nil {
+			err.Expected = *schema.MaxLength
+		}
+	case "minItems":
+		if schema != nil {
+			err.Expected = schema.MinItems
+		}
+	case "maxItems":
+		if schema != nil && schema.MaxItems != nil {
+			err.Expected = *schema.MaxItems
+		}
+	case intOrStringExtension:
+		err.Expected = "integer or string"
+		err.Actual = jsonType(err.Value)
+	}
+	if err.Category == CategoryDeprecatedField || err.Category == CategoryDeprecatedLabel || err.Category == CategoryPolicyRule {
+		if len(err.Replacement) > 0 {
+			err.Expected = err.Replacement
+		}
+	}
+	return err
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64, float32, int, int32, int64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
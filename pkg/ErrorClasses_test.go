@@ -0,0 +1,124 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func Test_classifySchemaError(t *testing.T) {
+	maxLength := uint64(63)
+	tests := []struct {
+		name         string
+		err          *SchemaError
+		wantCategory ErrorCategory
+		wantCode     string
+		wantExpected interface{}
+		wantActual   interface{}
+	}{
+		{
+			name:         "unknown field",
+			err:          &SchemaError{SchemaField: "properties", Reason: `property "containerPort1" is unsupported`},
+			wantCategory: CategoryUnknownField,
+			wantCode:     "KDD101",
+			wantActual:   "containerPort1",
+		},
+		{
+			name:         "type mismatch",
+			err:          &SchemaError{SchemaField: "type", Value: "string", Schema: openapi3.NewIntegerSchema()},
+			wantCategory: CategoryTypeMismatch,
+			wantCode:     "KDD102",
+			wantExpected: "integer",
+			wantActual:   "string",
+		},
+		{
+			name:         "type mismatch of a raw value",
+			err:          &SchemaError{SchemaField: "type", Value: map[string]interface{}{}, Schema: openapi3.NewStringSchema()},
+			wantCategory: CategoryTypeMismatch,
+			wantCode:     "KDD102",
+			wantExpected: "string",
+			wantActual:   "object",
+		},
+		{
+			name:         "required property",
+			err:          &SchemaError{SchemaField: "required", Reason: `property "name" is missing`},
+			wantCategory: CategoryRequiredMissing,
+			wantCode:     "KDD103",
+			wantExpected: "name",
+		},
+		{
+			name:         "enum",
+			err:          &SchemaError{SchemaField: "enum", Value: "Sometimes", Schema: openapi3.NewStringSchema().WithEnum("Always", "Never")},
+			wantCategory: CategoryEnumViolation,
+			wantCode:     "KDD104",
+			wantExpected: []interface{}{"Always", "Never"},
+			wantActual:   "Sometimes",
+		},
+		{
+			name:         "max length",
+			err:          &SchemaError{SchemaField: "maxLength", Value: "name", Schema: &openapi3.Schema{MaxLength: &maxLength}},
+			wantCategory: CategoryMinMax,
+			wantCode:     "KDD108",
+			wantExpected: maxLength,
+			wantActual:   "name",
+		},
+		{
+			name:         "deprecated label",
+			err:          &SchemaError{SchemaField: wellKnownLabelSchemaField, Value: "beta.kubernetes.io/os", Replacement: "kubernetes.io/os"},
+			wantCategory: CategoryDeprecatedLabel,
+			wantCode:     "KDD202",
+			wantExpected: "kubernetes.io/os",
+			wantActual:   "beta.kubernetes.io/os",
+		},
+		{
+			name:         "category set by the validation is kept",
+			err:          &SchemaError{SchemaField: metadataSchemaField, Value: "Name_1", Category: CategoryFormatViolation},
+			wantCategory: CategoryFormatViolation,
+			wantCode:     "KDD106",
+			wantActual:   "Name_1",
+		},
+		{
+			name:         "unknown schema field",
+			err:          &SchemaError{SchemaField: "oneOf"},
+			wantCategory: CategoryInvalidValue,
+			wantCode:     "KDD110",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifySchemaError(tt.err)
+			if got.Category != tt.wantCategory || got.Code != tt.wantCode {
+				t.Errorf("classifySchemaError() = %s %s, want %s %s", got.Category, got.Code, tt.wantCategory, tt.wantCode)
+			}
+			if !reflect.DeepEqual(got.Expected, tt.wantExpected) {
+				t.Errorf("classifySchemaError() expected = %v, want %v", got.Expected, tt.wantExpected)
+			}
+			if !reflect.DeepEqual(got.Actual, tt.wantActual) {
+				t.Errorf("classifySchemaError() actual = %v, want %v", got.Actual, tt.wantActual)
+			}
+		})
+	}
+}
+
+func TestIsErrorClass(t *testing.T) {
+	nullValue := classifySchemaError(&SchemaError{SchemaField: "nullable", Reason: "Value is not nullable"})
+	tests := []struct {
+		name  string
+		err   *SchemaError
+		class string
+		want  bool
+	}{
+		{name: "category", err: nullValue, class: "null-value", want: true},
+		{name: "code", err: nullValue, class: "kdd105", want: true},
+		{name: "other category", err: nullValue, class: "type-mismatch", want: false},
+		{name: "unclassified error", err: &SchemaError{SchemaField: "properties"}, class: "KDD101", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsErrorClass(tt.err, tt.class); got != tt.want {
+				t.Errorf("IsErrorClass() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
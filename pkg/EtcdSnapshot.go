@@ -0,0 +1,164 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// etcdKeyBucket is the bucket of the etcd backend holding the revisions of
+// the keys, keyed by revision
+var etcdKeyBucket = []byte("key")
+
+// etcdTombstoneMark marks the revision deleting a key
+const etcdTombstoneMark = 't'
+
+// etcdRegistryPrefix is the prefix of the keys the apiserver stores objects at
+const etcdRegistryPrefix = "/registry/"
+
+var (
+	// storageProtobufPrefix prefixes the objects the apiserver stores as
+	// protobuf, see k8s.io/apimachinery/pkg/runtime/serializer/protobuf
+	storageProtobufPrefix = []byte{0x6b, 0x38, 0x73, 0x00}
+	// storageEncryptedPrefix prefixes the objects encrypted at rest
+	storageEncryptedPrefix = []byte("k8s:enc:")
+)
+
+// storageScheme holds the types objects stored as protobuf are decoded with,
+// those of k8s.io/api and the CustomResourceDefinitions
+var storageScheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(scheme.AddToScheme(storageScheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(storageScheme))
+	utilruntime.Must(apiextensionsv1beta1.AddToScheme(storageScheme))
+}
+
+// EtcdObject is the latest revision of an object stored in an etcd snapshot
+type EtcdObject struct {
+	Key    string
+	Object map[string]interface{}
+	// Err is set if the stored value could not be decoded, e.g. if it is
+	// encrypted at rest or stored as protobuf of a kind kubedd does not know
+	Err error
+}
+
+// ReadEtcdSnapshot reads the latest revision of every object the apiserver
+// stored in the etcd snapshot fileName, ordered by key. Objects are decoded
+// at the apiVersion they are stored at, protobuf values with the types of
+// storageScheme
+func ReadEtcdSnapshot(fileName string) ([]EtcdObject, error) {
+	db, err := bolt.Open(fileName, 0400, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	values := map[string][]byte{}
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(etcdKeyBucket)
+		if bucket == nil {
+			return fmt.Errorf("%s is not an etcd snapshot", fileName)
+		}
+		// revisions are ordered, the last one of a key is its current value
+		return bucket.ForEach(func(revision, value []byte) error {
+			kv := &mvccpb.KeyValue{}
+			if err := kv.Unmarshal(value); err != nil {
+				return err
+			}
+			key := string(kv.Key)
+			if !strings.HasPrefix(key, etcdRegistryPrefix) {
+				return nil
+			}
+			if isTombstone(revision) {
+				delete(values, key)
+				return nil
+			}
+			values[key] = append([]byte(nil), kv.Value...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	objects := make([]EtcdObject, 0, len(keys))
+	for _, key := range keys {
+		object, err := decodeStoredObject(values[key])
+		objects = append(objects, EtcdObject{Key: key, Object: object, Err: err})
+	}
+	return objects, nil
+}
+
+// isTombstone checks whether the revision, stored as 8 bytes of main revision,
+// a separator and 8 bytes of sub revision, is marked as a deletion
+func isTombstone(revision []byte) bool {
+	return len(revision) == 18 && revision[17] == etcdTombstoneMark
+}
+
+// decodeStoredObject decodes an object stored by the apiserver along with its
+// apiVersion and kind, whether it is stored as json or protobuf
+func decodeStoredObject(value []byte) (map[string]interface{}, error) {
+	switch {
+	case bytes.HasPrefix(value, storageEncryptedPrefix):
+		return nil, fmt.Errorf("the object is encrypted at rest")
+	case bytes.HasPrefix(value, storageProtobufPrefix):
+		object, gvk, err := protobuf.NewSerializer(storageScheme, storageScheme).Decode(value, nil, nil)
+		if runtime.IsNotRegisteredError(err) {
+			// the envelope names the kind, e.g. the APIServices of the
+			// aggregator
+			unknown := &runtime.Unknown{}
+			if err := unknown.Unmarshal(value[len(storageProtobufPrefix):]); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%s %s is stored as protobuf of a kind kubedd does not know", unknown.APIVersion, unknown.Kind)
+		}
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+		if err != nil {
+			return nil, err
+		}
+		decoded["apiVersion"], decoded["kind"] = gvk.GroupVersion().String(), gvk.Kind
+		return decoded, nil
+	default:
+		decoded := map[string]interface{}{}
+		if err := json.Unmarshal(value, &decoded); err != nil {
+			return nil, err
+		}
+		return decoded, nil
+	}
+}
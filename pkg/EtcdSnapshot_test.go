@@ -0,0 +1,134 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	appsv1 "k8s.io/api/apps/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+)
+
+type etcdRevision struct {
+	key       string
+	value     []byte
+	tombstone bool
+}
+
+// writeEtcdSnapshot writes revisions to a bolt database as the etcd backend
+// does
+func writeEtcdSnapshot(t *testing.T, fileName string, revisions []etcdRevision) {
+	db, err := bolt.Open(fileName, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucket(etcdKeyBucket)
+		if err != nil {
+			return err
+		}
+		for i, r := range revisions {
+			revision := make([]byte, 17, 18)
+			binary.BigEndian.PutUint64(revision, uint64(i+1))
+			revision[8] = '_'
+			if r.tombstone {
+				revision = append(revision, etcdTombstoneMark)
+			}
+			value, err := (&mvccpb.KeyValue{Key: []byte(r.key), Value: r.value}).Marshal()
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(revision, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func protobufObject(t *testing.T, object runtime.Object) []byte {
+	var buf bytes.Buffer
+	if err := protobuf.NewSerializer(storageScheme, storageScheme).Encode(object, &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func protobufDeployment(t *testing.T, name string) []byte {
+	return protobufObject(t, &appsv1.Deployment{
+		TypeMeta:   v1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "apps"},
+	})
+}
+
+// protobufAPIService encodes an APIService of the aggregator, whose types
+// kubedd does not know, as the apiserver stores it
+func protobufAPIService(t *testing.T) []byte {
+	unknown := &runtime.Unknown{
+		TypeMeta: runtime.TypeMeta{APIVersion: "apiregistration.k8s.io/v1", Kind: "APIService"},
+		Raw:      []byte{0x0a, 0x00},
+	}
+	raw, err := unknown.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(append([]byte(nil), storageProtobufPrefix...), raw...)
+}
+
+func TestReadEtcdSnapshot(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "snapshot.db")
+	writeEtcdSnapshot(t, fileName, []etcdRevision{
+		{key: "/registry/deployments/apps/web", value: protobufDeployment(t, "old")},
+		{key: "/registry/ingress/apps/web", value: []byte(`{"apiVersion": "extensions/v1beta1", "kind": "Ingress", "metadata": {"name": "web"}}`)},
+		{key: "/registry/deployments/apps/web", value: protobufDeployment(t, "web")},
+		{key: "/registry/deployments/apps/gone", value: protobufDeployment(t, "gone")},
+		{key: "/registry/deployments/apps/gone", tombstone: true},
+		{key: "/registry/secrets/apps/token", value: []byte("k8s:enc:aescbc:v1:key1:...")},
+		{key: "/registry/apiextensions.k8s.io/customresourcedefinitions/widgets.example.com", value: protobufObject(t, &apiextensionsv1.CustomResourceDefinition{
+			TypeMeta:   v1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "CustomResourceDefinition"},
+			ObjectMeta: v1.ObjectMeta{Name: "widgets.example.com"},
+		})},
+		{key: "/registry/apiregistration.k8s.io/apiservices/v1.apps", value: protobufAPIService(t)},
+		{key: "compact_rev_key", value: []byte("1")},
+	})
+	objects, err := ReadEtcdSnapshot(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 5 {
+		t.Fatalf("ReadEtcdSnapshot() = %d objects, want 5", len(objects))
+	}
+	crd, apiService, deployment, ingress, secret := objects[0], objects[1], objects[2], objects[3], objects[4]
+	if deployment.Key != "/registry/deployments/apps/web" || deployment.Err != nil {
+		t.Errorf("ReadEtcdSnapshot() deployment = %s %v", deployment.Key, deployment.Err)
+	}
+	if name := deployment.Object["metadata"].(map[string]interface{})["name"]; name != "web" || deployment.Object["apiVersion"] != "apps/v1" || deployment.Object["kind"] != "Deployment" {
+		t.Errorf("ReadEtcdSnapshot() deployment = %v, want the latest revision at apps/v1", deployment.Object)
+	}
+	if ingress.Err != nil || ingress.Object["apiVersion"] != "extensions/v1beta1" {
+		t.Errorf("ReadEtcdSnapshot() ingress = %v %v", ingress.Object, ingress.Err)
+	}
+	if secret.Err == nil {
+		t.Error("ReadEtcdSnapshot() should fail to decode the encrypted secret")
+	}
+	if crd.Err != nil || crd.Object["kind"] != "CustomResourceDefinition" || crd.Object["apiVersion"] != "apiextensions.k8s.io/v1" {
+		t.Errorf("ReadEtcdSnapshot() crd = %v %v, want the protobuf CustomResourceDefinition decoded", crd.Object, crd.Err)
+	}
+	if apiService.Err == nil || !strings.Contains(apiService.Err.Error(), "apiregistration.k8s.io/v1 APIService") {
+		t.Errorf("ReadEtcdSnapshot() apiservice = %v, want the unknown kind named", apiService.Err)
+	}
+	if _, err := ReadEtcdSnapshot(filepath.Join(t.TempDir(), "missing.db")); err == nil {
+		t.Error("ReadEtcdSnapshot() of a missing snapshot should fail")
+	}
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	formatQuantity      = "quantity"
+	formatDNSLabel      = "dns-label"
+	formatDNSSubdomain  = "dns-subdomain"
+	formatDNS1035Label  = "dns-1035-label"
+	formatQualifiedName = "qualified-name"
+	formatLabelValue    = "label-value"
+	formatPortName      = "port-name"
+	formatDuration      = "duration"
+	formatCIDR          = "cidr"
+	formatMAC           = "mac"
+)
+
+// formatAssignments maps the properties of the kubernetes components to the
+// formats the apiserver enforces on them but the openapi spec doesn't declare.
+// A property followed by `*` refers to the values of a map property. The labels
+// of ObjectMeta are left to the metadata validation, which checks their keys too
+var formatAssignments = map[string]string{
+	"io.k8s.api.core.v1.Container.name":                                 formatDNSLabel,
+	"io.k8s.api.core.v1.EphemeralContainer.name":                        formatDNSLabel,
+	"io.k8s.api.core.v1.Volume.name":                                    formatDNSLabel,
+	"io.k8s.api.core.v1.ContainerPort.name":                             formatPortName,
+	"io.k8s.api.core.v1.ServicePort.name":                               formatDNSLabel,
+	"io.k8s.apimachinery.pkg.apis.meta.v1.LabelSelector.matchLabels*":   formatLabelValue,
+	"io.k8s.apimachinery.pkg.apis.meta.v1.LabelSelectorRequirement.key": formatQualifiedName,
+	"io.k8s.api.core.v1.NodeSelectorRequirement.key":                    formatQualifiedName,
+	"io.k8s.api.core.v1.TopologySpreadConstraint.topologyKey":           formatQualifiedName,
+	"io.k8s.api.core.v1.PodAffinityTerm.topologyKey":                    formatQualifiedName,
+	"io.k8s.api.core.v1.PodSpec.nodeSelector*":                          formatLabelValue,
+	"io.k8s.api.core.v1.ServiceSpec.selector*":                          formatLabelValue,
+}
+
+// init registers the formats, duration, cidr and mac are not assigned to any
+// field but declared by the schemas of custom resources
+func init() {
+	openapi3.DefineStringFormatCallback(formatQuantity, isQuantity)
+	openapi3.DefineStringFormatCallback(formatDNSLabel, formatChecker(validation.IsDNS1123Label))
+	openapi3.DefineStringFormatCallback(formatDNSSubdomain, formatChecker(validation.IsDNS1123Subdomain))
+	openapi3.DefineStringFormatCallback(formatDNS1035Label, formatChecker(validation.IsDNS1035Label))
+	openapi3.DefineStringFormatCallback(formatQualifiedName, formatChecker(validation.IsQualifiedName))
+	openapi3.DefineStringFormatCallback(formatLabelValue, formatChecker(validation.IsValidLabelValue))
+	openapi3.DefineStringFormatCallback(formatPortName, formatChecker(validation.IsValidPortName))
+	openapi3.DefineStringFormatCallback(formatDuration, isDuration)
+	openapi3.DefineStringFormatCallback(formatCIDR, isCIDR)
+	openapi3.DefineStringFormatCallback(formatMAC, isMAC)
+}
+
+// formatChecker adapts the apimachinery validation functions, which return a
+// list of error messages, to the openapi3 format callbacks
+func formatChecker(fn func(value string) []string) openapi3.FormatCallback {
+	return func(value string) error {
+		if errs := fn(value); len(errs) > 0 {
+			return fmt.Errorf("invalid value %q: %s", value, strings.Join(errs, "; "))
+		}
+		return nil
+	}
+}
+
+func isQuantity(value string) error {
+	if _, err := resource.ParseQuantity(value); err != nil {
+		return fmt.Errorf("invalid quantity %q: %v", value, err)
+	}
+	return nil
+}
+
+func isDuration(value string) error {
+	if _, err := time.ParseDuration(value); err != nil {
+		return fmt.Errorf("invalid duration %q: %v", value, err)
+	}
+	return nil
+}
+
+func isCIDR(value string) error {
+	if _, _, err := net.ParseCIDR(value); err != nil {
+		return fmt.Errorf("invalid cidr %q: %v", value, err)
+	}
+	return nil
+}
+
+func isMAC(value string) error {
+	if _, err := net.ParseMAC(value); err != nil {
+		return fmt.Errorf("invalid mac address %q: %v", value, err)
+	}
+	return nil
+}
+
+// assignFormats sets the formats from formatAssignments on the components
+// of doc, properties missing in this kubernetes version are skipped
+func assignFormats(doc *openapi3.T) {
+	for key, format := range formatAssignments {
+		index := strings.LastIndex(key, ".")
+		component, property := key[:index], key[index+1:]
+		ref, ok := doc.Components.Schemas[component]
+		if !ok || ref.Value == nil {
+			continue
+		}
+		mapValues := strings.HasSuffix(property, "*")
+		property = strings.TrimSuffix(property, "*")
+		prop, ok := ref.Value.Properties[property]
+		if !ok || prop.Value == nil || len(prop.Ref) > 0 {
+			continue
+		}
+		target := prop.Value
+		if mapValues {
+			if target.AdditionalProperties == nil || target.AdditionalProperties.Value == nil {
+				continue
+			}
+			target = target.AdditionalProperties.Value
+		}
+		if target.Type == "string" && len(target.Format) == 0 {
+			target.Format = format
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package pkg
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// loadTestSpec loads the trimmed down kubernetes openapi spec in testdata
+func loadTestSpec(t *testing.T) *kubeSpec {
+	data, err := ioutil.ReadFile("testdata/swagger.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	openapi, err := loadOpenApi2(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newKubeSpec(openapi)
+}
+
+const badPod = `
+{
+  "apiVersion": "v1",
+  "kind": "Pod",
+  "metadata": {
+    "name": "web",
+    "labels": {
+      "app": "nginx/web"
+    }
+  },
+  "spec": {
+    "nodeSelector": {
+      "disk": "ssd"
+    },
+    "containers": [
+      {
+        "name": "Web_Server",
+        "image": "nginx",
+        "ports": [
+          {
+            "name": "http-port-too-long",
+            "containerPort": 80
+          }
+        ],
+        "resources": {
+          "limits": {
+            "cpu": 1,
+            "memory": "128Mi"
+          },
+          "requests": {
+            "memory": "128 megabytes"
+          }
+        }
+      }
+    ]
+  }
+}`
+
+func TestFormats(t *testing.T) {
+	ks := loadTestSpec(t)
+	result, err := ks.ValidateJson(badPod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for _, e := range result.ErrorsForOriginal {
+		if e.SchemaField == metadataSchemaField {
+			continue
+		}
+		path := strings.Join(e.JSONPointer(), "/")
+		got[path] = true
+		if e.Category != CategoryFormatViolation || len(e.Reason) == 0 {
+			t.Errorf("error at %s = %s %q, want a %s with a reason", path, e.Category, e.Reason, CategoryFormatViolation)
+		}
+		if path == "spec/containers/0/resources/requests/memory" && (e.Expected != formatQuantity || !strings.Contains(e.Reason, "invalid quantity")) {
+			t.Errorf("error at %s = %v %q, want the quantity format", path, e.Expected, e.Reason)
+		}
+	}
+	want := []string{
+		"spec/containers/0/name",
+		"spec/containers/0/ports/0/name",
+		"spec/containers/0/resources/requests/memory",
+	}
+	for _, w := range want {
+		if !got[w] {
+			t.Errorf("expected a format error at %s, got %v", w, got)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d errors %v, want %d", len(got), got, len(want))
+	}
+}
+
+// TestCustomResourceFormats checks the formats which are only declared by the
+// schemas of custom resources
+func TestCustomResourceFormats(t *testing.T) {
+	tests := []struct {
+		format  string
+		value   string
+		wantErr bool
+	}{
+		{formatDuration, "1h30m", false},
+		{formatDuration, "30 seconds", true},
+		{formatCIDR, "10.0.0.0/8", false},
+		{formatCIDR, "10.0.0.0", true},
+		{formatMAC, "00:00:5e:00:53:01", false},
+		{formatMAC, "00:00:5e", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.value, func(t *testing.T) {
+			err := openapi3.NewStringSchema().WithFormat(tt.format).VisitJSON(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VisitJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
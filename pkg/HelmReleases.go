@@ -0,0 +1,220 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"helm.sh/helm/v3/pkg/release"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// helmReleaseSelector selects the secrets helm stores its releases in, named
+// sh.helm.release.v1.<release>.v<revision>
+const helmReleaseSelector = "owner=helm"
+
+const helmReleaseKey = "release"
+
+var secretsResource = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+var magicGzip = []byte{0x1f, 0x8b, 0x08}
+
+// HelmRelease is the latest revision of a helm release along with the secret
+// it is stored in
+type HelmRelease struct {
+	*release.Release
+	Secret *unstructured.Unstructured
+}
+
+// ChartName returns the name and version of the chart the release was installed
+// from
+func (r *HelmRelease) ChartName() string {
+	if r.Release.Chart == nil || r.Release.Chart.Metadata == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s-%s", r.Release.Chart.Metadata.Name, r.Release.Chart.Metadata.Version)
+}
+
+// FetchHelmReleases reads the latest revision of each helm release of the
+// selected namespaces from its release secret. Uninstalled releases are
+// skipped as they are not upgraded anymore
+func (c *Cluster) FetchHelmReleases(conf *Config) ([]*HelmRelease, error) {
+	objList, err := c.clientset.Resource(secretsResource).List(context.Background(), v1.ListOptions{LabelSelector: helmReleaseSelector})
+	if err != nil {
+		return nil, err
+	}
+	var releases []*HelmRelease
+	for _, secret := range latestReleaseSecrets(objList.Items) {
+		namespace := secret.GetNamespace()
+		if Contains(namespace, conf.IgnoreNamespaces) {
+			continue
+		}
+		if len(conf.SelectNamespaces) > 0 && !Contains(namespace, conf.SelectNamespaces) {
+			continue
+		}
+		if secret.GetLabels()["status"] == string(release.StatusUninstalled) {
+			continue
+		}
+		rls, err := decodeReleaseSecret(secret)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode helm release secret %s/%s: %v", namespace, secret.GetName(), err)
+		}
+		releases = append(releases, &HelmRelease{Release: rls, Secret: secret})
+	}
+	return releases, nil
+}
+
+// UpdateHelmRelease stores the release in its secret, after backing up the
+// secret as it was read to backupDir. The path of the backup is returned
+func (c *Cluster) UpdateHelmRelease(rls *HelmRelease, backupDir string) (string, error) {
+	backup, err := backupReleaseSecret(rls.Secret, backupDir)
+	if err != nil {
+		return "", err
+	}
+	secret := rls.Secret.DeepCopy()
+	data, err := encodeRelease(rls.Release)
+	if err != nil {
+		return backup, err
+	}
+	if err := unstructured.SetNestedField(secret.Object, base64.StdEncoding.EncodeToString([]byte(data)), "data", helmReleaseKey); err != nil {
+		return backup, err
+	}
+	updated, err := c.clientset.Resource(secretsResource).Namespace(secret.GetNamespace()).Update(context.Background(), secret, v1.UpdateOptions{})
+	if err != nil {
+		return backup, err
+	}
+	rls.Secret = updated
+	return backup, nil
+}
+
+// latestReleaseSecrets picks the secret of the highest revision of every
+// release, releases are identified by their namespace and name
+func latestReleaseSecrets(secrets []unstructured.Unstructured) []*unstructured.Unstructured {
+	var keys []string
+	latest := map[string]*unstructured.Unstructured{}
+	revisions := map[string]int{}
+	for i := range secrets {
+		labels := secrets[i].GetLabels()
+		revision, err := strconv.Atoi(labels["version"])
+		if err != nil || len(labels["name"]) == 0 {
+			continue
+		}
+		key := fmt.Sprintf("%s/%s", secrets[i].GetNamespace(), labels["name"])
+		if _, ok := latest[key]; !ok {
+			keys = append(keys, key)
+		} else if revision <= revisions[key] {
+			continue
+		}
+		latest[key] = &secrets[i]
+		revisions[key] = revision
+	}
+	out := make([]*unstructured.Unstructured, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, latest[key])
+	}
+	return out
+}
+
+// decodeReleaseSecret decodes the release stored in secret, which is the
+// base64 encoded, gzipped json of the release
+func decodeReleaseSecret(secret *unstructured.Unstructured) (*release.Release, error) {
+	data, ok, err := unstructured.NestedString(secret.Object, "data", helmReleaseKey)
+	if err != nil || !ok {
+		return nil, fmt.Errorf("secret has no %s data", helmReleaseKey)
+	}
+	// the secret data is base64 encoded on top of the encoding of helm
+	encoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRelease(string(encoded))
+}
+
+// decodeRelease decodes a release as encoded by the helm storage drivers,
+// releases stored before helm compressed them are not gzipped
+func decodeRelease(data string) (*release.Release, error) {
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(b, magicGzip) {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if b, err = ioutil.ReadAll(r); err != nil {
+			return nil, err
+		}
+	}
+	var rls release.Release
+	if err := json.Unmarshal(b, &rls); err != nil {
+		return nil, err
+	}
+	return &rls, nil
+}
+
+// encodeRelease encodes rls as the helm storage drivers do
+func encodeRelease(rls *release.Release) (string, error) {
+	b, err := json.Marshal(rls)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(b); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// backupReleaseSecret writes secret to backupDir as yaml, which can be
+// restored with kubectl apply. The server populated fields are dropped
+func backupReleaseSecret(secret *unstructured.Unstructured, backupDir string) (string, error) {
+	backup := secret.DeepCopy()
+	for _, field := range serverPopulatedMetadata {
+		unstructured.RemoveNestedField(backup.Object, "metadata", field)
+	}
+	data, err := yaml.Marshal(backup.Object)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(backupDir, fmt.Sprintf("%s.%s.yaml", secret.GetNamespace(), secret.GetName()))
+	return path, ioutil.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,110 @@
+package pkg
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	"sigs.k8s.io/yaml"
+)
+
+// releaseSecret stores rls as helm does
+func releaseSecret(t *testing.T, rls *release.Release, status release.Status) *unstructured.Unstructured {
+	data, err := encodeRelease(rls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"type":       "helm.sh/release.v1",
+		"data":       map[string]interface{}{helmReleaseKey: base64.StdEncoding.EncodeToString([]byte(data))},
+	}}
+	secret.SetName(fmt.Sprintf("sh.helm.release.v1.%s.v%d", rls.Name, rls.Version))
+	secret.SetNamespace(rls.Namespace)
+	secret.SetResourceVersion("1")
+	secret.SetLabels(map[string]string{
+		"owner":   "helm",
+		"name":    rls.Name,
+		"status":  string(status),
+		"version": fmt.Sprintf("%d", rls.Version),
+	})
+	return secret
+}
+
+func testHelmCluster(secrets ...*unstructured.Unstructured) *Cluster {
+	objects := make([]runtime.Object, 0, len(secrets))
+	for _, secret := range secrets {
+		objects = append(objects, secret)
+	}
+	clientset := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{secretsResource: "SecretList"}, objects...)
+	return &Cluster{clientset: clientset}
+}
+
+func TestCluster_FetchHelmReleases(t *testing.T) {
+	cluster := testHelmCluster(
+		releaseSecret(t, &release.Release{Name: "web", Namespace: "apps", Version: 1, Manifest: "old"}, release.StatusSuperseded),
+		releaseSecret(t, &release.Release{Name: "web", Namespace: "apps", Version: 2, Manifest: "new"}, release.StatusDeployed),
+		releaseSecret(t, &release.Release{Name: "web", Namespace: "staging", Version: 1, Manifest: "staging"}, release.StatusDeployed),
+		releaseSecret(t, &release.Release{Name: "gone", Namespace: "apps", Version: 3, Manifest: "gone"}, release.StatusUninstalled),
+		releaseSecret(t, &release.Release{Name: "dns", Namespace: "kube-system", Version: 1, Manifest: "dns"}, release.StatusDeployed),
+	)
+	conf := NewDefaultConfig()
+	conf.IgnoreNamespaces = []string{"kube-system"}
+	releases, err := cluster.FetchHelmReleases(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, rls := range releases {
+		got[fmt.Sprintf("%s/%s", rls.Namespace, rls.Name)] = rls.Manifest
+	}
+	want := map[string]string{"apps/web": "new", "staging/web": "staging"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("FetchHelmReleases() = %v, want %v", got, want)
+	}
+}
+
+func TestCluster_UpdateHelmRelease(t *testing.T) {
+	secret := releaseSecret(t, &release.Release{Name: "web", Namespace: "apps", Version: 2, Manifest: "apiVersion: extensions/v1beta1"}, release.StatusDeployed)
+	cluster := testHelmCluster(secret)
+	releases, err := cluster.FetchHelmReleases(NewDefaultConfig())
+	if err != nil || len(releases) != 1 {
+		t.Fatalf("FetchHelmReleases() = %v, %v", releases, err)
+	}
+	releases[0].Manifest = "apiVersion: apps/v1"
+	backupDir := t.TempDir()
+	backup, err := cluster.UpdateHelmRelease(releases[0], backupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup != filepath.Join(backupDir, "apps.sh.helm.release.v1.web.v2.yaml") {
+		t.Errorf("UpdateHelmRelease() backup = %s", backup)
+	}
+	data, err := ioutil.ReadFile(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backedUp := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(data, &backedUp.Object); err != nil {
+		t.Fatal(err)
+	}
+	if backedUp.GetResourceVersion() != "" {
+		t.Errorf("UpdateHelmRelease() backup keeps resourceVersion %s", backedUp.GetResourceVersion())
+	}
+	if rls, err := decodeReleaseSecret(backedUp); err != nil || rls.Manifest != "apiVersion: extensions/v1beta1" {
+		t.Errorf("UpdateHelmRelease() backup = %v, %v, want the original manifest", rls, err)
+	}
+	releases, err = cluster.FetchHelmReleases(NewDefaultConfig())
+	if err != nil || len(releases) != 1 || releases[0].Manifest != "apiVersion: apps/v1" {
+		t.Errorf("FetchHelmReleases() after update = %v, %v, want the rewritten manifest", releases, err)
+	}
+}
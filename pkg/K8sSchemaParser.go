@@ -40,6 +40,8 @@ const (
 	intOrStringPath   = "components.schemas.io\\.k8s\\.apimachinery\\.pkg\\.util\\.intstr\\.IntOrString"
 	intOrStringType   = `{"oneOf":[{"type": "string"},{"type": "integer"}]}`
 	intOrStringFormat = "definitions.io\\.k8s\\.apimachinery\\.pkg\\.util\\.intstr\\.IntOrString.format"
+	quantityPath      = "components.schemas.io\\.k8s\\.apimachinery\\.pkg\\.api\\.resource\\.Quantity"
+	quantityType      = `{"oneOf":[{"type": "string"},{"type": "number"}],"format": "quantity"}`
 	alphaVersion      = 1
 	betaVersion       = 2
 	gaVersion         = 3
@@ -53,6 +55,9 @@ type KubeChecker interface {
 	ValidateYaml(spec string, releaseVersion string) (ValidationResult, error)
 	ValidateObject(spec map[string]interface{}, releaseVersion string) (ValidationResult, error)
 	GetKinds(releaseVersion string) ([]schema.GroupVersionKind, error)
+	GetServedKinds(releaseVersion string) ([]schema.GroupVersionKind, error)
+	GetServedResources(releaseVersion string) (map[schema.GroupVersionResource]schema.GroupVersionKind, error)
+	RegisterCRD(crd map[string]interface{}) error
 }
 
 type kubeCheckerImpl struct {
@@ -98,7 +103,9 @@ func (k *kubeCheckerImpl) load(data []byte, releaseVersion string) error {
 		//kLog.Debug(fmt.Sprintf("%v", err))
 		return err
 	}
-	k.versionMap[releaseVersion] = newKubeSpec(openapi)
+	ks := newKubeSpec(openapi)
+	ks.releaseVersion = releaseVersion
+	k.versionMap[releaseVersion] = ks
 	return nil
 }
 
@@ -160,6 +167,10 @@ func loadOpenApi2(data []byte) (*openapi3.T, error) {
 		//kLog.Debug(fmt.Sprintf("%v", err))
 		return nil, err
 	}
+	stringData, err = sjson.SetRaw(stringData, quantityPath, quantityType)
+	if err != nil {
+		return nil, err
+	}
 
 	loader := &openapi3.Loader{Context: ctx}
 	doc, err = loader.LoadFromData([]byte(stringData))
@@ -174,8 +185,12 @@ func loadOpenApi2(data []byte) (*openapi3.T, error) {
 		return nil, err
 	}
 	for _, v := range doc.Components.Schemas {
-		v.Value.AdditionalPropertiesAllowed = openapi3.BoolPtr(false)
+		if !allowsUnknownFields(v.Value) {
+			v.Value.AdditionalPropertiesAllowed = openapi3.BoolPtr(false)
+		}
 	}
+	assignFormats(doc)
+	assignListTypes(doc)
 	return doc, nil
 }
 
@@ -211,6 +226,26 @@ func (k *kubeCheckerImpl) GetKinds(releaseVersion string) ([]schema.GroupVersion
 	return k.versionMap[releaseVersion].fetchLatestKinds(), nil
 }
 
+// GetServedKinds returns every group, version and kind served by
+// releaseVersion, not only the latest version of each kind
+func (k *kubeCheckerImpl) GetServedKinds(releaseVersion string) ([]schema.GroupVersionKind, error) {
+	err := k.LoadFromUrl(releaseVersion, false)
+	if err != nil {
+		return make([]schema.GroupVersionKind, 0), err
+	}
+	return k.versionMap[releaseVersion].fetchServedKinds(), nil
+}
+
+// GetServedResources returns the group, version and resource of every kind
+// served by releaseVersion, mapped to the kind
+func (k *kubeCheckerImpl) GetServedResources(releaseVersion string) (map[schema.GroupVersionResource]schema.GroupVersionKind, error) {
+	err := k.LoadFromUrl(releaseVersion, false)
+	if err != nil {
+		return map[schema.GroupVersionResource]schema.GroupVersionKind{}, err
+	}
+	return k.versionMap[releaseVersion].fetchServedResources(), nil
+}
+
 func (k *kubeCheckerImpl) IsVersionSupported(releaseVersion, apiVersion, kind string) bool {
 	err := k.LoadFromUrl(releaseVersion, false)
 	if err != nil {
@@ -222,14 +257,27 @@ func (k *kubeCheckerImpl) IsVersionSupported(releaseVersion, apiVersion, kind st
 type kubeSpec struct {
 	*openapi3.T
 	kindInfoMap             map[string][]*KindInfo
+	schemaNames             map[*openapi3.Schema]string
+	releaseVersion          string
 }
 
 func newKubeSpec(openapi *openapi3.T) *kubeSpec {
 	ks := &kubeSpec{T: openapi}
 	ks.kindInfoMap = ks.buildKindInfoMap()
+	ks.schemaNames = ks.buildSchemaNames()
 	return ks
 }
 
+func (ks *kubeSpec) buildSchemaNames() map[*openapi3.Schema]string {
+	names := map[*openapi3.Schema]string{}
+	for component, value := range ks.T.Components.Schemas {
+		if value.Value != nil {
+			names[value.Value] = component
+		}
+	}
+	return names
+}
+
 func (ks *kubeSpec) buildGVKRestPathMap() map[string]string {
 	pathMap := map[string]string{}
 	for path, value := range ks.T.Paths {
@@ -279,6 +327,7 @@ func (ks *kubeSpec) buildKindInfoMap() map[string][]*KindInfo {
 			}
 			if p, ok := restPath[gvkKey]; ok {
 				ki.RestPath = p
+				ki.Namespaced = isNamespaced(p)
 			}
 			kindMap[kind] = append(kindMap[kind], &ki)
 		}
@@ -314,6 +363,60 @@ func (ks *kubeSpec) fetchLatestKinds() []schema.GroupVersionKind {
 	return gvka
 }
 
+// fetchServedResources maps the resources of the kind infos with a rest path
+// to their kinds
+func (ks *kubeSpec) fetchServedResources() map[schema.GroupVersionResource]schema.GroupVersionKind {
+	resources := map[schema.GroupVersionResource]schema.GroupVersionKind{}
+	for _, info := range ks.kindInfoMap {
+		for _, ki := range info {
+			resource := restPathResource(ki.RestPath)
+			if len(resource) == 0 {
+				continue
+			}
+			gvr := schema.GroupVersionResource{Group: ki.Group, Version: ki.Version, Resource: resource}
+			resources[gvr] = gvr.GroupVersion().WithKind(componentKind(ki.ComponentKey))
+		}
+	}
+	return resources
+}
+
+// restPathResource returns the resource of a rest path, e.g. deployments of
+// /apis/apps/v1/namespaces/{namespace}/deployments/{name}
+func restPathResource(restPath string) string {
+	parts := strings.Split(strings.Trim(restPath, "/"), "/")
+	switch {
+	case len(parts) > 2 && parts[0] == "api":
+		parts = parts[2:]
+	case len(parts) > 3 && parts[0] == "apis":
+		parts = parts[3:]
+	default:
+		return ""
+	}
+	if len(parts) > 2 && parts[0] == "namespaces" && parts[1] == "{namespace}" {
+		parts = parts[2:]
+	}
+	return parts[0]
+}
+
+// fetchServedKinds returns the group, version and kind of every kind info with
+// a rest path, the kind is cased as in its schema
+func (ks *kubeSpec) fetchServedKinds() []schema.GroupVersionKind {
+	var gvka []schema.GroupVersionKind
+	for _, info := range ks.kindInfoMap {
+		for _, ki := range info {
+			if len(ki.RestPath) == 0 {
+				continue
+			}
+			gvka = append(gvka, schema.GroupVersionKind{
+				Group:   ki.Group,
+				Version: ki.Version,
+				Kind:    componentKind(ki.ComponentKey),
+			})
+		}
+	}
+	return gvka
+}
+
 func (ks *kubeSpec) IsVersionSupported(apiVersion, kind string) bool {
 	if kim, ok := ks.kindInfoMap[strings.ToLower(kind)]; ok {
 		//fmt.Printf("found %s \n", kind)
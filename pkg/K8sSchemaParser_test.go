@@ -426,4 +426,21 @@ func Test_compareVersion(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func Test_restPathResource(t *testing.T) {
+	tests := map[string]string{
+		"/api/v1/namespaces":                                         "namespaces",
+		"/api/v1/namespaces/{name}/finalize":                         "namespaces",
+		"/api/v1/namespaces/{namespace}/pods/{name}/status":          "pods",
+		"/apis/apps/v1/namespaces/{namespace}/deployments":           "deployments",
+		"/apis/apiextensions.k8s.io/v1/customresourcedefinitions":    "customresourcedefinitions",
+		"/apis/example.com/v1/namespaces/{namespace}/widgets/{name}": "widgets",
+		"/version": "",
+	}
+	for restPath, want := range tests {
+		if got := restPathResource(restPath); got != want {
+			t.Errorf("restPathResource(%s) = %s, want %s", restPath, got, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// selectorSchemaField is the schema field of the errors raised for selectors
+// of removed apis
+const selectorSchemaField = "selector"
+
+// KustomizationSelectorPaths are the paths of the group, version and kind
+// selectors of a kustomization
+var KustomizationSelectorPaths = []string{
+	"patches/*/target",
+	"patchesJson6902/*/target",
+	"replacements/*/source",
+	"replacements/*/targets/*/select",
+	"replacements/*/targets/*/reject/*",
+}
+
+// TransformerConfigSelectorPaths are the paths of the field specs of the
+// transformer configurations of a kustomization, e.g. images/0
+var TransformerConfigSelectorPaths = []string{"*/*"}
+
+// CheckSelectors reports the group and version selectors at paths of document
+// which select built-in apis not served by targetVersion, whether or not
+// sourceVersion serves them. These selectors silently stop matching once the
+// resources are migrated. Selectors of custom resource groups, which neither
+// version knows, are skipped
+func CheckSelectors(document map[string]interface{}, paths []string, kubeC KubeChecker, sourceVersion, targetVersion string) []*SchemaError {
+	var matches []pathMatch
+	for _, path := range paths {
+		matches = append(matches, findPaths(document, splitRulePath(path), nil)...)
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return strings.Join(matches[i].path, "/") < strings.Join(matches[j].path, "/")
+	})
+	sourceKinds, _ := kubeC.GetServedKinds(sourceVersion)
+	targetKinds, err := kubeC.GetServedKinds(targetVersion)
+	if err != nil {
+		return nil
+	}
+	latestKinds, _ := kubeC.GetKinds(targetVersion)
+	var errs []*SchemaError
+	for _, m := range matches {
+		selector, ok := m.value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		group, version, kind := selectorField(selector, "group"), selectorField(selector, "version"), selectorField(selector, "kind")
+		if len(group) == 0 && len(version) == 0 {
+			continue
+		}
+		if !selectsBuiltin(group, kind, sourceKinds, latestKinds) || selectsAny(targetKinds, group, version, kind) {
+			continue
+		}
+		field := "version"
+		if len(version) == 0 {
+			field = "group"
+		}
+		selected := strings.TrimPrefix(fmt.Sprintf("%s/%s %s", group, version, kind), "/")
+		errs = append(errs, classifySchemaError(&SchemaError{
+			Value:       selector[field],
+			reversePath: reversePointer(append(append([]string(nil), m.path...), field)),
+			SchemaField: selectorSchemaField,
+			Reason:      fmt.Sprintf("selects %s which is not served by kubernetes %s, the selector stops matching once migrated", strings.TrimSpace(selected), targetVersion),
+			Replacement: latestGroupVersion(latestKinds, kind),
+			Severity:    SeverityError,
+		}))
+	}
+	return errs
+}
+
+// selectsBuiltin checks whether a selector of group and kind selects built-in
+// apis rather than custom resources. The groups of custom resources contain a
+// dot, a group with a dot is built-in if the source or the target version
+// knows it, e.g. networking.k8s.io unlike gateway.networking.k8s.io. A selector
+// without group is checked if its kind is a built-in one
+func selectsBuiltin(group, kind string, sourceKinds, targetKinds []schema.GroupVersionKind) bool {
+	if len(group) > 0 {
+		return !strings.Contains(group, ".") || selectsAny(sourceKinds, group, "", "") || selectsAny(targetKinds, group, "", "")
+	}
+	return len(kind) > 0 && (selectsAny(sourceKinds, "", "", kind) || selectsAny(targetKinds, "", "", kind))
+}
+
+func selectorField(selector map[string]interface{}, field string) string {
+	value, _ := selector[field].(string)
+	return value
+}
+
+// selectsAny checks whether a selector of group, version and kind, each of
+// which matches anything if empty, selects any of gvks
+func selectsAny(gvks []schema.GroupVersionKind, group, version, kind string) bool {
+	for _, gvk := range gvks {
+		if len(group) > 0 && group != gvk.Group {
+			continue
+		}
+		if len(version) > 0 && version != gvk.Version {
+			continue
+		}
+		if len(kind) > 0 && !strings.EqualFold(kind, gvk.Kind) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// latestGroupVersion returns the latest group version of kind in gvks
+func latestGroupVersion(gvks []schema.GroupVersionKind, kind string) string {
+	if len(kind) == 0 {
+		return ""
+	}
+	for _, gvk := range gvks {
+		if strings.EqualFold(kind, gvk.Kind) {
+			return gvk.GroupVersion().String()
+		}
+	}
+	return ""
+}
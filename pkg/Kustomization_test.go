@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+const staleKustomization = `
+patches:
+  - target:
+      group: extensions
+      version: v1beta1
+      kind: Deployment
+  - target:
+      group: apps
+      version: v1
+      kind: Deployment
+  - target:
+      kind: Deployment
+replacements:
+  - source:
+      version: v1
+      kind: Pod
+    targets:
+      - select:
+          group: extensions
+          kind: Deployment
+        reject:
+          - group: example.com
+            version: v1
+          - group: gateway.networking.k8s.io
+            version: v1alpha2
+            kind: HTTPRoute
+`
+
+// testKubeChecker serves the test spec as source version 1.21 and, without
+// extensions/v1beta1, as target version 1.22
+func testKubeChecker(t *testing.T) *kubeCheckerImpl {
+	target := loadTestSpec(t)
+	var served []*KindInfo
+	for _, ki := range target.kindInfoMap["deployment"] {
+		if ki.Group != "extensions" {
+			served = append(served, ki)
+		}
+	}
+	target.kindInfoMap["deployment"] = served
+	return &kubeCheckerImpl{versionMap: map[string]*kubeSpec{"1.21": loadTestSpec(t), "1.22": target}}
+}
+
+func TestCheckSelectors(t *testing.T) {
+	document := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(staleKustomization), &document); err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		path        string
+		replacement string
+	}{
+		{path: "patches/0/target/version", replacement: "apps/v1"},
+		{path: "replacements/0/targets/0/select/group", replacement: "apps/v1"},
+	}
+	// the selectors are stale whether or not the source version serves them
+	for _, sourceVersion := range []string{"1.21", "1.22"} {
+		errs := CheckSelectors(document, KustomizationSelectorPaths, testKubeChecker(t), sourceVersion, "1.22")
+		if len(errs) != len(want) {
+			t.Fatalf("CheckSelectors() from %s = %v, want %d errors", sourceVersion, errs, len(want))
+		}
+		for i, err := range errs {
+			if path := strings.Join(err.JSONPointer(), "/"); path != want[i].path || err.Replacement != want[i].replacement {
+				t.Errorf("CheckSelectors()[%d] = %s %s, want %s %s", i, path, err.Replacement, want[i].path, want[i].replacement)
+			}
+			if !IsErrorClass(err, "KDD203") || err.Severity != SeverityError {
+				t.Errorf("CheckSelectors()[%d] = %s %s, want a stale selector error", i, err.Code, err.Severity)
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ListItem is a resource of a document, with its path within the document
+type ListItem struct {
+	Object map[string]interface{}
+	Path   []string
+}
+
+// IsList checks whether object is a List, as written by kubectl get, or a
+// typed list like DeploymentList
+func IsList(object map[string]interface{}) bool {
+	kind, _ := object["kind"].(string)
+	if !strings.HasSuffix(kind, "List") {
+		return false
+	}
+	_, ok := object["items"].([]interface{})
+	return ok
+}
+
+// ExpandList returns the items of object if it is a list, nested lists are
+// expanded as well, otherwise object itself. The items of typed lists, which
+// the apiserver returns without apiVersion and kind, get those of the list
+func ExpandList(object map[string]interface{}) []ListItem {
+	return expandList(object, nil)
+}
+
+func expandList(object map[string]interface{}, path []string) []ListItem {
+	if !IsList(object) {
+		return []ListItem{{Object: object, Path: path}}
+	}
+	kind, _ := object["kind"].(string)
+	apiVersion, _ := object["apiVersion"].(string)
+	itemKind := strings.TrimSuffix(kind, "List")
+	var items []ListItem
+	for i, item := range object["items"].([]interface{}) {
+		itemObject, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if len(itemKind) > 0 {
+			if _, ok := itemObject["kind"]; !ok {
+				itemObject["kind"] = itemKind
+			}
+			if _, ok := itemObject["apiVersion"]; !ok && len(apiVersion) > 0 {
+				itemObject["apiVersion"] = apiVersion
+			}
+		}
+		itemPath := append(append([]string(nil), path...), "items", strconv.Itoa(i))
+		items = append(items, expandList(itemObject, itemPath)...)
+	}
+	return items
+}
+
+// serverPopulatedMetadata are the fields of the metadata which the apiserver
+// sets and which are not part of manifests
+var serverPopulatedMetadata = []string{
+	"uid",
+	"resourceVersion",
+	"generation",
+	"creationTimestamp",
+	"deletionTimestamp",
+	"deletionGracePeriodSeconds",
+	"selfLink",
+	"managedFields",
+}
+
+// serverPopulatedAnnotations are the annotations which the apiserver or its
+// clients set on the objects they manage
+var serverPopulatedAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"deployment.kubernetes.io/revision",
+}
+
+// NormalizeObject removes the fields the apiserver populates from object, so
+// that objects read from the cluster or its dumps validate like manifests
+func NormalizeObject(object map[string]interface{}) map[string]interface{} {
+	delete(object, "status")
+	metadata, ok := object["metadata"].(map[string]interface{})
+	if !ok {
+		return object
+	}
+	for _, field := range serverPopulatedMetadata {
+		delete(metadata, field)
+	}
+	if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+		for _, annotation := range serverPopulatedAnnotations {
+			delete(annotations, annotation)
+		}
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+	return object
+}
@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandList(t *testing.T) {
+	deployment := map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": map[string]interface{}{"name": "a"}}
+	tests := []struct {
+		name   string
+		object map[string]interface{}
+		want   []ListItem
+	}{
+		{
+			name:   "not a list",
+			object: deployment,
+			want:   []ListItem{{Object: deployment}},
+		},
+		{
+			name: "list",
+			object: map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": []interface{}{
+				deployment,
+				map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": []interface{}{deployment}},
+			}},
+			want: []ListItem{
+				{Object: deployment, Path: []string{"items", "0"}},
+				{Object: deployment, Path: []string{"items", "1", "items", "0"}},
+			},
+		},
+		{
+			name: "typed list items get the apiVersion and kind of the list",
+			object: map[string]interface{}{"apiVersion": "apps/v1", "kind": "DeploymentList", "items": []interface{}{
+				map[string]interface{}{"metadata": map[string]interface{}{"name": "a"}},
+			}},
+			want: []ListItem{{Object: deployment, Path: []string{"items", "0"}}},
+		},
+		{
+			name:   "kind ending with List without items",
+			object: map[string]interface{}{"apiVersion": "v1", "kind": "PodList"},
+			want:   []ListItem{{Object: map[string]interface{}{"apiVersion": "v1", "kind": "PodList"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandList(tt.object); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeObject(t *testing.T) {
+	object := map[string]interface{}{
+		"kind": "Deployment",
+		"metadata": map[string]interface{}{
+			"name":              "a",
+			"uid":               "7e5c",
+			"resourceVersion":   "42",
+			"creationTimestamp": "2021-01-01T00:00:00Z",
+			"managedFields":     []interface{}{},
+			"annotations": map[string]interface{}{
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+			},
+			"labels": map[string]interface{}{"app": "a"},
+		},
+		"status": map[string]interface{}{"replicas": 1},
+	}
+	want := map[string]interface{}{
+		"kind": "Deployment",
+		"metadata": map[string]interface{}{
+			"name":   "a",
+			"labels": map[string]interface{}{"app": "a"},
+		},
+	}
+	if got := NormalizeObject(object); !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeObject() = %v, want %v", got, want)
+	}
+}
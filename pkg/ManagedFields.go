@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ManagerUsage is the use of an apiVersion which is deprecated or removed in
+// the target kubernetes version by a field manager, e.g. helm, an operator
+// or kubectl, as recorded in the managedFields of the objects it wrote
+type ManagerUsage struct {
+	Manager          string
+	APIVersion       string
+	Kind             string
+	LatestAPIVersion string
+	Removed          bool
+	// Objects is the number of objects the manager last wrote with the
+	// apiVersion
+	Objects int
+}
+
+// apiVersionStatus is the status of an apiVersion and kind in the target
+// kubernetes version
+type apiVersionStatus struct {
+	removed, deprecated bool
+	latestAPIVersion    string
+}
+
+// AnalyzeManagedFields aggregates the apiVersions the field managers of objs
+// last wrote with by manager, keeping those which are deprecated or removed
+// in targetVersion. Kinds without a schema, e.g. custom resources whose
+// definition is not registered, are skipped
+func AnalyzeManagedFields(objs []unstructured.Unstructured, kubeC KubeChecker, targetVersion string) []ManagerUsage {
+	statuses := map[string]*apiVersionStatus{}
+	usages := map[string]*ManagerUsage{}
+	for _, obj := range objs {
+		kind := obj.GetKind()
+		// an object is counted once per manager and apiVersion, though the
+		// manager may own fields of both the object and its status
+		counted := map[string]bool{}
+		for _, entry := range obj.GetManagedFields() {
+			if len(entry.APIVersion) == 0 {
+				continue
+			}
+			versionKind := entry.APIVersion + "/" + kind
+			status, ok := statuses[versionKind]
+			if !ok {
+				status = checkAPIVersion(kubeC, targetVersion, entry.APIVersion, kind)
+				statuses[versionKind] = status
+			}
+			if status == nil || (!status.removed && !status.deprecated) {
+				continue
+			}
+			key := fmt.Sprintf("%s\x00%s", entry.Manager, versionKind)
+			if counted[key] {
+				continue
+			}
+			counted[key] = true
+			usage, ok := usages[key]
+			if !ok {
+				usage = &ManagerUsage{
+					Manager:          entry.Manager,
+					APIVersion:       entry.APIVersion,
+					Kind:             kind,
+					LatestAPIVersion: status.latestAPIVersion,
+					Removed:          status.removed,
+				}
+				usages[key] = usage
+			}
+			usage.Objects++
+		}
+	}
+	result := make([]ManagerUsage, 0, len(usages))
+	for _, usage := range usages {
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Manager != result[j].Manager {
+			return result[i].Manager < result[j].Manager
+		}
+		if result[i].APIVersion != result[j].APIVersion {
+			return result[i].APIVersion < result[j].APIVersion
+		}
+		return result[i].Kind < result[j].Kind
+	})
+	return result
+}
+
+// checkAPIVersion validates an object of apiVersion and kind without any
+// fields against targetVersion, nil is returned if kind has no schema
+func checkAPIVersion(kubeC KubeChecker, targetVersion, apiVersion, kind string) *apiVersionStatus {
+	result, err := kubeC.ValidateObject(map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{},
+	}, targetVersion)
+	if err != nil || !result.ValidatedAgainstSchema {
+		return nil
+	}
+	status := &apiVersionStatus{
+		removed:    !kubeC.IsVersionSupported(targetVersion, apiVersion, kind),
+		deprecated: result.Deprecated,
+	}
+	if result.LatestAPIVersion != apiVersion {
+		status.latestAPIVersion = result.LatestAPIVersion
+	}
+	return status
+}
+
+// ManagerUsageSection reports usages as a section, see AnalyzeManagedFields
+func ManagerUsageSection(usages []ManagerUsage) Section {
+	section := Section{
+		Title:   "Field managers writing through deprecated or removed API Version's",
+		Headers: []string{"Manager", "Kind", "API Version (Last Written)", "Replace With API Version (Latest Available)", "Status", "Objects"},
+	}
+	for _, usage := range usages {
+		status := "deprecated"
+		if usage.Removed {
+			status = "removed"
+		}
+		section.Rows = append(section.Rows, []string{usage.Manager, usage.Kind, usage.APIVersion, usage.LatestAPIVersion, status, fmt.Sprintf("%d", usage.Objects)})
+	}
+	return section
+}
@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func managedObject(apiVersion, kind, name string, entries ...v1.ManagedFieldsEntry) unstructured.Unstructured {
+	obj := testObject(apiVersion, kind, "apps", name)
+	obj.SetManagedFields(entries)
+	return *obj
+}
+
+func TestAnalyzeManagedFields(t *testing.T) {
+	objs := []unstructured.Unstructured{
+		managedObject("apps/v1", "Deployment", "web",
+			v1.ManagedFieldsEntry{Manager: "helm", Operation: v1.ManagedFieldsOperationUpdate, APIVersion: "extensions/v1beta1"},
+			v1.ManagedFieldsEntry{Manager: "kube-controller-manager", Operation: v1.ManagedFieldsOperationUpdate, APIVersion: "apps/v1", Subresource: "status"},
+		),
+		managedObject("apps/v1", "Deployment", "api",
+			v1.ManagedFieldsEntry{Manager: "helm", Operation: v1.ManagedFieldsOperationUpdate, APIVersion: "extensions/v1beta1"},
+			v1.ManagedFieldsEntry{Manager: "helm", Operation: v1.ManagedFieldsOperationUpdate, APIVersion: "extensions/v1beta1", Subresource: "status"},
+		),
+		managedObject("v1", "Pod", "web-1",
+			v1.ManagedFieldsEntry{Manager: "kubelet", Operation: v1.ManagedFieldsOperationUpdate, APIVersion: "v1"},
+		),
+		managedObject("example.com/v1", "Widget", "web",
+			v1.ManagedFieldsEntry{Manager: "widget-operator", Operation: v1.ManagedFieldsOperationApply, APIVersion: "example.com/v1alpha1"},
+		),
+	}
+	got := AnalyzeManagedFields(objs, testKubeChecker(t), "1.22")
+	want := []ManagerUsage{
+		{Manager: "helm", APIVersion: "extensions/v1beta1", Kind: "Deployment", LatestAPIVersion: "apps/v1", Removed: true, Objects: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AnalyzeManagedFields() = %+v, want %+v", got, want)
+	}
+	section := ManagerUsageSection(got)
+	if len(section.Rows) != 1 || !reflect.DeepEqual(section.Rows[0], []string{"helm", "Deployment", "extensions/v1beta1", "apps/v1", "removed", "2"}) {
+		t.Errorf("ManagerUsageSection() = %v", section.Rows)
+	}
+}
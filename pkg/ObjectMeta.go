@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/api/validation/path"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const metadataSchemaField = "metadata"
+
+// nameValidators are the name rules of the kinds which differ from the
+// default of a DNS subdomain, as enforced by the apiserver
+var nameValidators = map[string]apivalidation.ValidateNameFunc{
+	"namespace":          apivalidation.ValidateNamespaceName,
+	"service":            apivalidation.NameIsDNS1035Label,
+	"role":               path.ValidatePathSegmentName,
+	"clusterrole":        path.ValidatePathSegmentName,
+	"rolebinding":        path.ValidatePathSegmentName,
+	"clusterrolebinding": path.ValidatePathSegmentName,
+}
+
+// metadataErrorCategories classifies the errors of the metadata validation,
+// the invalid values are names and keys not matching their syntax
+var metadataErrorCategories = map[field.ErrorType]ErrorCategory{
+	field.ErrorTypeRequired:     CategoryRequiredMissing,
+	field.ErrorTypeInvalid:      CategoryFormatViolation,
+	field.ErrorTypeTooLong:      CategoryMinMax,
+	field.ErrorTypeTooMany:      CategoryMinMax,
+	field.ErrorTypeDuplicate:    CategoryDuplicateValue,
+	field.ErrorTypeNotSupported: CategoryEnumViolation,
+	field.ErrorTypeForbidden:    CategoryInvalidValue,
+}
+
+// maxNameLengths are the kinds whose names are limited further than their
+// name rules allow, as the name is used to generate names of other objects
+var maxNameLengths = map[string]int{
+	"cronjob": 52,
+}
+
+// validateMetadata validates metadata of object the same way the apiserver does
+// on creation. The namespace is not required for namespaced kinds as it is
+// defaulted on apply, but is forbidden for cluster scoped ones. The name is not
+// required when generateName is set
+func validateMetadata(object map[string]interface{}, kind string, namespaced bool) []*SchemaError {
+	if _, ok := object["metadata"].(map[string]interface{}); !ok {
+		return nil
+	}
+	kind = strings.ToLower(kind)
+	nameFn, ok := nameValidators[kind]
+	if !ok {
+		nameFn = apivalidation.NameIsDNSSubdomain
+	}
+	obj := &unstructured.Unstructured{Object: object}
+	fldPath := field.NewPath("metadata")
+	errs := apivalidation.ValidateObjectMetaAccessor(obj, namespaced, nameFn, fldPath)
+	if maxLength, ok := maxNameLengths[kind]; ok && len(obj.GetName()) > maxLength {
+		errs = append(errs, field.TooLong(fldPath.Child("name"), obj.GetName(), maxLength))
+	}
+	var schemaErrors []*SchemaError
+	for _, e := range errs {
+		if e.Type == field.ErrorTypeRequired && e.Field == fldPath.Child("namespace").String() {
+			continue
+		}
+		// the apiserver generates the name before validating the object
+		if e.Type == field.ErrorTypeRequired && e.Field == fldPath.Child("name").String() && len(obj.GetGenerateName()) > 0 {
+			continue
+		}
+		schemaErrors = append(schemaErrors, classifySchemaError(&SchemaError{
+			Value:       e.BadValue,
+			reversePath: reversePointer(fieldPathToPointer(e.Field)),
+			SchemaField: metadataSchemaField,
+			Reason:      e.ErrorBody(),
+			Category:    metadataErrorCategories[e.Type],
+		}))
+	}
+	return schemaErrors
+}
+
+// fieldPathToPointer converts the paths of field.Error, e.g.
+// metadata.ownerReferences[0].uid, or of validation rules, e.g.
+// .spec['app.kubernetes.io/name'], to the segments of a json pointer
+func fieldPathToPointer(fieldPath string) []string {
+	var segments []string
+	var current strings.Builder
+	inIndex := false
+	for _, r := range fieldPath {
+		switch {
+		case r == '[' && !inIndex:
+			inIndex = true
+			if current.Len() > 0 {
+				segments = append(segments, current.String())
+				current.Reset()
+			}
+		case r == ']' && inIndex:
+			inIndex = false
+			segments = append(segments, strings.Trim(current.String(), `'"`))
+			current.Reset()
+		case r == '.' && !inIndex:
+			if current.Len() > 0 {
+				segments = append(segments, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		segments = append(segments, current.String())
+	}
+	return segments
+}
+
+func reversePointer(pointer []string) []string {
+	reversed := make([]string, 0, len(pointer))
+	for i := len(pointer) - 1; i >= 0; i-- {
+		reversed = append(reversed, pointer[i])
+	}
+	return reversed
+}
+
+// isNamespaced derives the scope of a kind from its rest path
+func isNamespaced(restPath string) bool {
+	return strings.Contains(restPath, "/namespaces/{namespace}/")
+}
+
+func (ks *kubeSpec) kindInfoForComponent(kind, component string) (*KindInfo, error) {
+	for _, ki := range ks.kindInfoMap[strings.ToLower(kind)] {
+		if ki.ComponentKey == component {
+			return ki, nil
+		}
+	}
+	return nil, fmt.Errorf("no kind info for %s in %s", kind, component)
+}
@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_fieldPathToPointer(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "metadata.name", want: []string{"metadata", "name"}},
+		{path: "metadata.ownerReferences[0].uid", want: []string{"metadata", "ownerReferences", "0", "uid"}},
+		{path: "metadata.labels[app.kubernetes.io/name]", want: []string{"metadata", "labels", "app.kubernetes.io/name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := fieldPathToPointer(tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fieldPathToPointer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateMetadata(t *testing.T) {
+	ks := loadTestSpec(t)
+	tests := []struct {
+		name   string
+		object string
+		want   []string
+	}{
+		{
+			name:   "valid pod without namespace",
+			object: `{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "web"}, "spec": {"containers": [{"name": "web"}]}}`,
+		},
+		{
+			name:   "invalid name and label key",
+			object: `{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "Web_1", "labels": {"-app": "web"}}, "spec": {"containers": [{"name": "web"}]}}`,
+			want:   []string{"metadata/name", "metadata/labels"},
+		},
+		{
+			name:   "one finding per bad label",
+			object: `{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "web", "labels": {"app": "nginx/web", "tier": "front end", "team": "web"}}, "spec": {"containers": [{"name": "web"}]}}`,
+			want:   []string{"metadata/labels", "metadata/labels"},
+		},
+		{
+			name:   "generate name",
+			object: `{"apiVersion": "v1", "kind": "Pod", "metadata": {"generateName": "web-"}, "spec": {"containers": [{"name": "web"}]}}`,
+		},
+		{
+			name:   "missing name",
+			object: `{"apiVersion": "v1", "kind": "Pod", "metadata": {}, "spec": {"containers": [{"name": "web"}]}}`,
+			want:   []string{"metadata/name"},
+		},
+		{
+			name:   "namespace on cluster scoped kind",
+			object: `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "prod", "namespace": "default"}}`,
+			want:   []string{"metadata/namespace"},
+		},
+		{
+			name:   "annotations too large",
+			object: `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "prod", "annotations": {"note": "` + strings.Repeat("a", 256*1024) + `"}}}`,
+			want:   []string{"metadata/annotations"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object := make(map[string]interface{})
+			if err := json.Unmarshal([]byte(tt.object), &object); err != nil {
+				t.Fatal(err)
+			}
+			result, err := ks.ValidateObject(object)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range result.ErrorsForOriginal {
+				if pointer := e.JSONPointer(); e.SchemaField == metadataSchemaField || (len(pointer) > 0 && pointer[0] == "metadata") {
+					got = append(got, strings.Join(pointer, "/"))
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateObject() metadata errors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
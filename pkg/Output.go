@@ -40,9 +40,26 @@ import (
 type OutputManager interface {
 	PutBulk(r []ValidationResult) error
 	Put(r ValidationResult) error
+	// PutSection reports a section besides the results of the resources. The
+	// json output reports them next to the results, the tap output as
+	// diagnostics after them. Sections without rows are left out by every
+	// output, so the json output stays an array of results unless there is a
+	// section to report
+	PutSection(section Section) error
 	Flush() error
 }
 
+// Section is a table of a report besides the results of the resources, e.g.
+// the field managers which write through deprecated apis
+type Section struct {
+	Title   string
+	Headers []string
+	Rows    [][]string
+	// Highlighted marks the rows, by index, which the stdout output colors
+	// to stand out
+	Highlighted []bool
+}
+
 const (
 	outputSTD  = "stdout"
 	outputJSON = "json"
@@ -92,11 +109,18 @@ func (s *STDOutputManager) PutBulk(results []ValidationResult) error {
 	var deprecated []ValidationResult
 	var newerVersion []ValidationResult
 	var unchanged []ValidationResult
+	var unvalidated []ValidationResult
+	var unparsable []ValidationResult
 
 	for _, result := range results {
 		if len(result.Kind) == 0 {
+			if len(result.ErrorsForOriginal) > 0 {
+				unparsable = append(unparsable, result)
+			}
 			continue
-		}else if result.Deleted {
+		} else if !result.ValidatedAgainstSchema {
+			unvalidated = append(unvalidated, result)
+		} else if result.Deleted {
 			deleted = append(deleted, result)
 		} else if result.Deprecated && len(result.LatestAPIVersion) > 0 {
 			deprecated = append(deprecated, result)
@@ -106,7 +130,8 @@ func (s *STDOutputManager) PutBulk(results []ValidationResult) error {
 			newerVersion = append(newerVersion, result)
 		} else {
 			if len(result.ErrorsForOriginal) > 0 || len(result.ErrorsForLatest) > 0 ||
-				len(result.DeprecationForOriginal) > 0 || len(result.DeprecationForLatest) > 0 {
+				len(result.DeprecationForOriginal) > 0 || len(result.DeprecationForLatest) > 0 ||
+				len(result.WarningsForOriginal) > 0 || len(result.WarningsForLatest) > 0 {
 				unchanged = append(unchanged, result)
 			}
 		}
@@ -121,6 +146,7 @@ func (s *STDOutputManager) PutBulk(results []ValidationResult) error {
 		fmt.Println("")
 		s.ValidationErrorTableBodyOutput(deleted, false)
 		s.DeprecationTableBodyOutput(deleted, false)
+		s.WarningTableBodyOutput(deleted, false)
 	}
 	if len(deprecated) > 0 {
 		sort.Slice(deprecated, func(i, j int) bool {
@@ -134,6 +160,7 @@ func (s *STDOutputManager) PutBulk(results []ValidationResult) error {
 		s.ValidationErrorTableBodyOutput(deprecated, true)
 		s.DeprecationTableBodyOutput(deprecated, false)
 		s.ValidationErrorTableBodyOutput(deprecated, false)
+		s.WarningTableBodyOutput(deprecated, false)
 	}
 	if len(newerVersion) > 0 {
 		sort.Slice(newerVersion, func(i, j int) bool {
@@ -147,6 +174,7 @@ func (s *STDOutputManager) PutBulk(results []ValidationResult) error {
 		s.ValidationErrorTableBodyOutput(newerVersion, true)
 		s.DeprecationTableBodyOutput(newerVersion, false)
 		s.ValidationErrorTableBodyOutput(newerVersion, false)
+		s.WarningTableBodyOutput(newerVersion, false)
 	}
 	if len(unchanged) > 0 {
 
@@ -155,9 +183,23 @@ func (s *STDOutputManager) PutBulk(results []ValidationResult) error {
 		fmt.Println("")
 		s.DeprecationTableBodyOutput(unchanged, true)
 		s.ValidationErrorTableBodyOutput(unchanged, true)
+		s.WarningTableBodyOutput(unchanged, true)
+	}
+	if len(unvalidated) > 0 {
+		yellow := color.New(color.FgHiYellow, color.Underline).SprintFunc()
+		fmt.Printf("%s\n", yellow(">>>> Unvalidated resources, no schema found <<<<"))
+		s.UnvalidatedTableBodyOutput(unvalidated)
+		fmt.Println("")
+	}
+
+	if len(unparsable) > 0 {
+		red := color.New(color.FgHiRed, color.Underline).SprintFunc()
+		fmt.Printf("%s\n", red(">>>> Documents which could not be parsed or installed <<<<"))
+		s.ParseErrorTableBodyOutput(unparsable)
+		fmt.Println("")
 	}
 
-	if len(deleted)+len(deprecated)+len(newerVersion)+len(unchanged) == 0 {
+	if len(deleted)+len(deprecated)+len(newerVersion)+len(unchanged)+len(unvalidated)+len(unparsable) == 0 {
 		fmt.Printf("%s\n", green("Great!!! Everything will work as it is in new version without any changes"))
 	}
 	return nil
@@ -177,7 +219,10 @@ func (s *STDOutputManager) SummaryTableBodyOutput(results []ValidationResult) {
 		if result.IsVersionSupported == 2 {
 			migrationStatus = fmt.Sprintf("%s%s", "\033[31m", fmt.Sprintf("Alert! cannot migrate kubernetes version"))
 		}
-		t.Rows = append(t.Rows, []string{result.ResourceNamespace, result.ResourceName, result.Kind, result.APIVersion, result.LatestAPIVersion, migrationStatus})
+		if len(result.Suggestion) > 0 {
+			migrationStatus = fmt.Sprintf("%s%s", "\033[31m", fmt.Sprintf("unknown api group, did you mean %s?", result.Suggestion))
+		}
+		t.Rows = append(t.Rows, []string{result.ResourceNamespace, result.DisplayName(), result.Kind, result.APIVersion, result.LatestAPIVersion, migrationStatus})
 	}
 	t.WriteTable(os.Stdout, c)
 }
@@ -210,7 +255,7 @@ func (s *STDOutputManager) DeprecationTableBodyOutput(results []ValidationResult
 	if !currentVersion {
 		apiVersionHeader = "API Version (Latest Available)"
 	}
-	t := table.Table{Headers: []string{"Namespace", "Name", "Kind", apiVersionHeader, "Field", "Reason"}}
+	t := table.Table{Headers: []string{"Namespace", "Name", "Kind", apiVersionHeader, "Field", "Line", "Code", "Reason", "Replace With"}}
 	c := table.DefaultConfig()
 	c.TitleColorCode = ansi.ColorCode("cyan+bu")
 	c.AltColorCodes = []string{ansi.LightWhite, ansi.ColorCode("white+h:237")}
@@ -223,13 +268,22 @@ func (s *STDOutputManager) DeprecationTableBodyOutput(results []ValidationResult
 			errors = result.DeprecationForOriginal
 		}
 		for _, e := range errors {
-			t.Rows = append(t.Rows, []string{result.ResourceNamespace, result.ResourceName, result.Kind, apiVersion, strings.Join(e.JSONPointer(), "/"), e.Reason})
+			t.Rows = append(t.Rows, []string{result.ResourceNamespace, result.DisplayName(), result.Kind, apiVersion, strings.Join(e.JSONPointer(), "/"), e.Position.String(), e.Code, deprecationReason(e), e.Replacement})
 		}
 	}
 	t.WriteTable(os.Stdout, c)
 	fmt.Println("")
 }
 
+// deprecationReason prefixes the findings of user defined rules with the rule
+// id and severity
+func deprecationReason(e *SchemaError) string {
+	if len(e.RuleID) == 0 {
+		return e.Reason
+	}
+	return fmt.Sprintf("[%s] %s: %s", e.RuleID, e.Severity, e.Reason)
+}
+
 func (s *STDOutputManager) ValidationErrorTableBodyOutput(results []ValidationResult, currentVersion bool) {
 	hasData := false
 	for _, result := range results {
@@ -258,7 +312,7 @@ func (s *STDOutputManager) ValidationErrorTableBodyOutput(results []ValidationRe
 	if !currentVersion {
 		apiVersionHeader = "API Version (Latest Available)"
 	}
-	t := table.Table{Headers: []string{"Namespace", "Name", "Kind", apiVersionHeader, "Field", "Reason"}}
+	t := table.Table{Headers: []string{"Namespace", "Name", "Kind", apiVersionHeader, "Field", "Line", "Code", "Reason"}}
 	c := table.DefaultConfig()
 	c.TitleColorCode = ansi.ColorCode("cyan+bu")
 	c.AltColorCodes = []string{ansi.LightWhite, ansi.ColorCode("white+h:237")}
@@ -272,7 +326,7 @@ func (s *STDOutputManager) ValidationErrorTableBodyOutput(results []ValidationRe
 		}
 		for _, e := range errors {
 			if len(e.JSONPointer()) > 0 {
-				t.Rows = append(t.Rows, []string{result.ResourceNamespace, result.ResourceName, result.Kind, apiVersion, strings.Join(e.JSONPointer(), "/"), e.Reason})
+				t.Rows = append(t.Rows, []string{result.ResourceNamespace, result.DisplayName(), result.Kind, apiVersion, strings.Join(e.JSONPointer(), "/"), e.Position.String(), e.Code, e.DisplayReason()})
 			}
 		}
 	}
@@ -280,11 +334,113 @@ func (s *STDOutputManager) ValidationErrorTableBodyOutput(results []ValidationRe
 	fmt.Println("")
 }
 
+func (s *STDOutputManager) WarningTableBodyOutput(results []ValidationResult, currentVersion bool) {
+	hasData := false
+	for _, result := range results {
+		warnings := result.WarningsForLatest
+		if currentVersion {
+			warnings = result.WarningsForOriginal
+		}
+		if len(warnings) > 0 {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		return
+	}
+	if !currentVersion {
+		fmt.Println(hiWhite(">>> Warnings against latest api version, fields unknown to the schema <<<"))
+	} else {
+		fmt.Println(hiWhite(">>> Warnings against current api version, fields unknown to the schema <<<"))
+	}
+	apiVersionHeader := "API Version (Current Available)"
+	if !currentVersion {
+		apiVersionHeader = "API Version (Latest Available)"
+	}
+	t := table.Table{Headers: []string{"Namespace", "Name", "Kind", apiVersionHeader, "Field", "Line", "Code", "Reason"}}
+	c := table.DefaultConfig()
+	c.TitleColorCode = ansi.ColorCode("cyan+bu")
+	c.AltColorCodes = []string{ansi.LightWhite, ansi.ColorCode("white+h:237")}
+	c.ShowIndex = false
+	for _, result := range results {
+		warnings := result.WarningsForLatest
+		apiVersion := result.LatestAPIVersion
+		if currentVersion {
+			apiVersion = result.APIVersion
+			warnings = result.WarningsForOriginal
+		}
+		for _, e := range warnings {
+			t.Rows = append(t.Rows, []string{result.ResourceNamespace, result.DisplayName(), result.Kind, apiVersion, strings.Join(e.JSONPointer(), "/"), e.Position.String(), e.Code, e.DisplayReason()})
+		}
+	}
+	t.WriteTable(os.Stdout, c)
+	fmt.Println("")
+}
+
+func (s *STDOutputManager) UnvalidatedTableBodyOutput(results []ValidationResult) {
+	t := table.Table{Headers: []string{"Namespace", "Name", "Kind", "API Version", "Line", "Status", "Did You Mean"}}
+	c := table.DefaultConfig()
+	c.TitleColorCode = ansi.ColorCode("cyan+bu")
+	c.AltColorCodes = []string{ansi.LightWhite, ansi.ColorCode("white+h:237")}
+	c.ShowIndex = false
+	for _, result := range results {
+		t.Rows = append(t.Rows, []string{result.ResourceNamespace, result.DisplayName(), result.Kind, result.APIVersion, result.Position.String(), statusUnvalidated, result.Suggestion})
+	}
+	t.WriteTable(os.Stdout, c)
+}
+
+func (s *STDOutputManager) ParseErrorTableBodyOutput(results []ValidationResult) {
+	t := table.Table{Headers: []string{"File", "Document", "Line", "Code", "Reason"}}
+	c := table.DefaultConfig()
+	c.TitleColorCode = ansi.ColorCode("cyan+bu")
+	c.AltColorCodes = []string{ansi.LightWhite, ansi.ColorCode("white+h:237")}
+	c.ShowIndex = false
+	for _, result := range results {
+		for _, e := range result.ErrorsForOriginal {
+			document := ""
+			if e.Position != nil {
+				document = fmt.Sprintf("%d", e.Position.Document)
+			}
+			t.Rows = append(t.Rows, []string{result.FileName, document, e.Position.String(), e.Code, e.Reason})
+		}
+	}
+	t.WriteTable(os.Stdout, c)
+}
+
 func (s *STDOutputManager) Put(result ValidationResult) error {
 	openapi3.SchemaErrorDetailsDisabled = true
 	return nil
 }
 
+func (s *STDOutputManager) PutSection(section Section) error {
+	if len(section.Rows) == 0 {
+		return nil
+	}
+	yellow := color.New(color.FgHiYellow, color.Underline).SprintFunc()
+	fmt.Printf("%s\n", yellow(fmt.Sprintf(">>>> %s <<<<", section.Title)))
+	t := table.Table{Headers: section.Headers, Rows: section.Rows}
+	c := table.DefaultConfig()
+	c.TitleColorCode = ansi.ColorCode("cyan+bu")
+	c.AltColorCodes = []string{ansi.LightWhite, ansi.ColorCode("white+h:237")}
+	if len(section.Highlighted) > 0 {
+		// the table cycles through the color codes by row, a code per row
+		// colors the highlighted rows
+		codes := make([]string, len(section.Rows))
+		for i := range section.Rows {
+			codes[i] = c.AltColorCodes[i%len(c.AltColorCodes)]
+			if i < len(section.Highlighted) && section.Highlighted[i] {
+				codes[i] = ansi.Red
+			}
+		}
+		c.AltColorCodes = codes
+	}
+	c.ShowIndex = false
+	t.WriteTable(os.Stdout, c)
+	fmt.Println("")
+	return nil
+}
+
 func (s *STDOutputManager) Flush() error {
 	// no op
 	return nil
@@ -293,23 +449,105 @@ func (s *STDOutputManager) Flush() error {
 type status string
 
 const (
-	statusInvalid = "invalid"
-	statusValid   = "valid"
-	statusSkipped = "skipped"
+	statusInvalid     = "invalid"
+	statusValid       = "valid"
+	statusSkipped     = "skipped"
+	statusUnvalidated = "unvalidated"
 )
 
 type dataEvalResult struct {
-	Filename string   `json:"filename"`
-	Kind     string   `json:"kind"`
-	Status   status   `json:"status"`
-	Errors   []string `json:"errors"`
+	Filename   string    `json:"filename"`
+	Kind       string    `json:"kind"`
+	APIVersion string    `json:"apiVersion,omitempty"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	Address    string    `json:"address,omitempty"`
+	Status     status    `json:"status"`
+	Errors     []string  `json:"errors"`
+	Position   *Position `json:"position,omitempty"`
+	Suggestion string    `json:"suggestion,omitempty"`
+	Findings   []finding `json:"findings,omitempty"`
+}
+
+const (
+	findingError       = "error"
+	findingWarning     = "warning"
+	findingDeprecation = "deprecation"
+)
+
+// dataSection is a Section as reported by the json and tap outputs
+type dataSection struct {
+	Title   string     `json:"title"`
+	Headers []string   `json:"headers"`
+	Rows    [][]string `json:"rows"`
+}
+
+func newDataSection(section Section) dataSection {
+	return dataSection{Title: section.Title, Headers: section.Headers, Rows: section.Rows}
+}
+
+// finding is a classified validation error, warning or deprecation of a
+// resource against either its own api version or the latest one
+type finding struct {
+	Type       string        `json:"type"`
+	APIVersion string        `json:"apiVersion"`
+	Field      string        `json:"field"`
+	Code       string        `json:"code"`
+	Category   ErrorCategory `json:"category"`
+	Reason     string        `json:"reason"`
+	Expected   interface{}   `json:"expected,omitempty"`
+	Actual     interface{}   `json:"actual,omitempty"`
+	Suggestion string        `json:"suggestion,omitempty"`
+	RuleID     string        `json:"ruleId,omitempty"`
+	Severity   string        `json:"severity,omitempty"`
+	Position   *Position     `json:"position,omitempty"`
+}
+
+func (f finding) String() string {
+	location := f.Field
+	if f.Position != nil {
+		location = fmt.Sprintf("%s:%s %s", f.Position.File, f.Position, f.Field)
+	}
+	return fmt.Sprintf("%s: %s %s", location, f.Code, f.Reason)
+}
+
+// resultFindings lists the findings of r, those against the current api
+// version first
+func resultFindings(r ValidationResult) []finding {
+	var findings []finding
+	add := func(findingType, apiVersion string, errs []*SchemaError) {
+		for _, e := range errs {
+			findings = append(findings, finding{
+				Type:       findingType,
+				APIVersion: apiVersion,
+				Field:      strings.Join(e.JSONPointer(), "/"),
+				Code:       e.Code,
+				Category:   e.Category,
+				Reason:     e.Reason,
+				Expected:   e.Expected,
+				Actual:     e.Actual,
+				Suggestion: e.Suggestion,
+				RuleID:     e.RuleID,
+				Severity:   e.Severity,
+				Position:   e.Position,
+			})
+		}
+	}
+	add(findingError, r.APIVersion, r.ErrorsForOriginal)
+	add(findingWarning, r.APIVersion, r.WarningsForOriginal)
+	add(findingDeprecation, r.APIVersion, r.DeprecationForOriginal)
+	add(findingError, r.LatestAPIVersion, r.ErrorsForLatest)
+	add(findingWarning, r.LatestAPIVersion, r.WarningsForLatest)
+	add(findingDeprecation, r.LatestAPIVersion, r.DeprecationForLatest)
+	return findings
 }
 
 // jsonOutputManager reports `ccheck` results to `stdout` as a json array..
 type jsonOutputManager struct {
 	logger *log.Logger
 
-	data []dataEvalResult
+	data     []dataEvalResult
+	sections []dataSection
 }
 
 func newDefaultJSONOutputManager() *jsonOutputManager {
@@ -324,14 +562,17 @@ func newJSONOutputManager(l *log.Logger) *jsonOutputManager {
 
 func getStatus(r ValidationResult) status {
 	if r.Kind == "" {
+		if len(r.ErrorsForOriginal) > 0 {
+			return statusInvalid
+		}
 		return statusSkipped
 	}
 
 	if !r.ValidatedAgainstSchema {
-		return statusSkipped
+		return statusUnvalidated
 	}
 
-	if len(r.Errors) > 0 {
+	if len(r.Errors) > 0 || len(r.ErrorsForOriginal) > 0 || len(r.ErrorsForLatest) > 0 {
 		return statusInvalid
 	}
 
@@ -339,6 +580,11 @@ func getStatus(r ValidationResult) status {
 }
 
 func (j *jsonOutputManager) PutBulk(r []ValidationResult) error {
+	for _, result := range r {
+		if err := j.Put(result); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -352,17 +598,42 @@ func (j *jsonOutputManager) Put(r ValidationResult) error {
 	}
 
 	j.data = append(j.data, dataEvalResult{
-		Filename: r.FileName,
-		Kind:     r.Kind,
-		Status:   getStatus(r),
-		Errors:   errs,
+		Filename:   r.FileName,
+		Kind:       r.Kind,
+		APIVersion: r.APIVersion,
+		Namespace:  r.ResourceNamespace,
+		Name:       r.ResourceName,
+		Address:    r.Address,
+		Status:     getStatus(r),
+		Errors:     errs,
+		Position:   r.Position,
+		Suggestion: r.Suggestion,
+		Findings:   resultFindings(r),
 	})
 
 	return nil
 }
 
+func (j *jsonOutputManager) PutSection(section Section) error {
+	if len(section.Rows) == 0 {
+		return nil
+	}
+	j.sections = append(j.sections, newDataSection(section))
+	return nil
+}
+
+// Flush prints the results as a json array, or as the results of an object
+// besides the sections if any were reported
 func (j *jsonOutputManager) Flush() error {
-	b, err := json.Marshal(j.data)
+	// an empty array rather than null if nothing was reported
+	var report interface{} = append([]dataEvalResult{}, j.data...)
+	if len(j.sections) > 0 {
+		report = struct {
+			Results  []dataEvalResult `json:"results,omitempty"`
+			Sections []dataSection    `json:"sections"`
+		}{j.data, j.sections}
+	}
+	b, err := json.Marshal(report)
 	if err != nil {
 		return err
 	}
@@ -381,7 +652,8 @@ func (j *jsonOutputManager) Flush() error {
 type tapOutputManager struct {
 	logger *log.Logger
 
-	data []dataEvalResult
+	data     []dataEvalResult
+	sections []dataSection
 }
 
 // newDefaultTapOutManager instantiates a new instance of tapOutputManager
@@ -399,6 +671,11 @@ func newTAPOutputManager(l *log.Logger) *tapOutputManager {
 }
 
 func (j *tapOutputManager) PutBulk(r []ValidationResult) error {
+	for _, result := range r {
+		if err := j.Put(result); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -407,6 +684,11 @@ func (j *tapOutputManager) Put(r ValidationResult) error {
 	for _, e := range r.Errors {
 		errs = append(errs, e.String())
 	}
+	for _, f := range resultFindings(r) {
+		if f.Type == findingError {
+			errs = append(errs, f.String())
+		}
+	}
 
 	j.data = append(j.data, dataEvalResult{
 		Filename: r.FileName,
@@ -418,8 +700,20 @@ func (j *tapOutputManager) Put(r ValidationResult) error {
 	return nil
 }
 
+func (j *tapOutputManager) PutSection(section Section) error {
+	if len(section.Rows) == 0 {
+		return nil
+	}
+	j.sections = append(j.sections, newDataSection(section))
+	return nil
+}
+
 func (j *tapOutputManager) Flush() error {
 	issues := len(j.data)
+	if issues == 0 {
+		// the plan of a run without tests
+		j.logger.Print("1..0")
+	}
 	if issues > 0 {
 		total := 0
 		for _, r := range j.data {
@@ -441,7 +735,7 @@ func (j *tapOutputManager) Flush() error {
 			}
 			if r.Status == "valid" {
 				j.logger.Print("ok ", count, " - ", r.Filename, kindMarker)
-			} else if r.Status == "skipped" {
+			} else if r.Status == statusSkipped || r.Status == statusUnvalidated {
 				j.logger.Print("ok ", count, " - ", r.Filename, kindMarker, " # SKIP")
 			} else if r.Status == "invalid" {
 				for i, e := range r.Errors {
@@ -455,5 +749,13 @@ func (j *tapOutputManager) Flush() error {
 			}
 		}
 	}
+	// sections are not tests, they are reported as diagnostics
+	for _, section := range j.sections {
+		j.logger.Print("# ", section.Title)
+		j.logger.Print("# ", strings.Join(section.Headers, " | "))
+		for _, row := range section.Rows {
+			j.logger.Print("# ", strings.Join(row, " | "))
+		}
+	}
 	return nil
 }
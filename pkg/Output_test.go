@@ -201,3 +201,99 @@ ok 1 - deployment.yaml (Deployment) # SKIP
 		})
 	}
 }
+
+func Test_outputManager_putSection(t *testing.T) {
+	section := ManagerUsageSection([]ManagerUsage{
+		{Manager: "helm", Kind: "Ingress", APIVersion: "extensions/v1beta1", LatestAPIVersion: "networking.k8s.io/v1", Removed: true, Objects: 2},
+	})
+	vr := ValidationResult{
+		FileName:               "deployment.yaml",
+		Kind:                   "Deployment",
+		ValidatedAgainstSchema: true,
+	}
+
+	buf := new(bytes.Buffer)
+	j := newJSONOutputManager(log.New(buf, "", 0))
+	assert.NoError(t, j.Put(vr))
+	assert.NoError(t, j.PutSection(section))
+	assert.NoError(t, j.PutSection(ManagerUsageSection(nil)))
+	assert.NoError(t, j.Flush())
+	assert.Equal(t, `{
+	"results": [
+		{
+			"filename": "deployment.yaml",
+			"kind": "Deployment",
+			"status": "valid",
+			"errors": []
+		}
+	],
+	"sections": [
+		{
+			"title": "Field managers writing through deprecated or removed API Version's",
+			"headers": [
+				"Manager",
+				"Kind",
+				"API Version (Last Written)",
+				"Replace With API Version (Latest Available)",
+				"Status",
+				"Objects"
+			],
+			"rows": [
+				[
+					"helm",
+					"Ingress",
+					"extensions/v1beta1",
+					"networking.k8s.io/v1",
+					"removed",
+					"2"
+				]
+			]
+		}
+	]
+}
+`, buf.String())
+
+	// sections without rows leave the results an array
+	buf = new(bytes.Buffer)
+	j = newJSONOutputManager(log.New(buf, "", 0))
+	assert.NoError(t, j.Put(vr))
+	assert.NoError(t, j.PutSection(ManagerUsageSection(nil)))
+	assert.NoError(t, j.PutSection(RequestedAPIUsageSection(nil)))
+	assert.NoError(t, j.Flush())
+	assert.Equal(t, `[
+	{
+		"filename": "deployment.yaml",
+		"kind": "Deployment",
+		"status": "valid",
+		"errors": []
+	}
+]
+`, buf.String())
+
+	buf = new(bytes.Buffer)
+	tap := newTAPOutputManager(log.New(buf, "", 0))
+	assert.NoError(t, tap.Put(vr))
+	assert.NoError(t, tap.PutSection(section))
+	assert.NoError(t, tap.PutSection(ManagerUsageSection(nil)))
+	assert.NoError(t, tap.Flush())
+	assert.Equal(t, `1..1
+ok 1 - deployment.yaml (Deployment)
+# Field managers writing through deprecated or removed API Version's
+# Manager | Kind | API Version (Last Written) | Replace With API Version (Latest Available) | Status | Objects
+# helm | Ingress | extensions/v1beta1 | networking.k8s.io/v1 | removed | 2
+`, buf.String())
+}
+
+func Test_outputManager_flushEmpty(t *testing.T) {
+	buf := new(bytes.Buffer)
+	j := newJSONOutputManager(log.New(buf, "", 0))
+	assert.NoError(t, j.PutSection(AuditUsageSection(nil)))
+	assert.NoError(t, j.Flush())
+	assert.Equal(t, "[]\n", buf.String())
+
+	buf = new(bytes.Buffer)
+	tap := newTAPOutputManager(log.New(buf, "", 0))
+	assert.NoError(t, tap.PutSection(AuditUsageSection(nil)))
+	assert.NoError(t, tap.Flush())
+	assert.Equal(t, "1..0\n", buf.String())
+}
@@ -0,0 +1,215 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/common/expfmt"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// requestedDeprecatedAPIsMetric is the gauge the apiserver sets for every
+// deprecated api requested since it started
+const requestedDeprecatedAPIsMetric = "apiserver_requested_deprecated_apis"
+
+const metricsPath = "/metrics"
+
+// RequestedDeprecatedAPI is a deprecated api which was requested from the
+// apiserver, as reported by its metrics
+type RequestedDeprecatedAPI struct {
+	Group          string
+	Version        string
+	Resource       string
+	Subresource    string
+	RemovedRelease string
+}
+
+// GroupVersionResource returns the group, version and resource requested
+func (r RequestedDeprecatedAPI) GroupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: r.Group, Version: r.Version, Resource: r.Resource}
+}
+
+// RequestedAPISource is the source of the deprecated apis requested from the
+// apiserver of a cluster
+type RequestedAPISource interface {
+	FetchRequestedDeprecatedAPIs() ([]RequestedDeprecatedAPI, error)
+}
+
+// FetchRequestedDeprecatedAPIs scrapes the metrics of the apiserver for the
+// deprecated apis which were requested since it started
+func (c *Cluster) FetchRequestedDeprecatedAPIs() ([]RequestedDeprecatedAPI, error) {
+	data, err := c.disco.RESTClient().Get().AbsPath(metricsPath).DoRaw(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return ParseRequestedDeprecatedAPIs(bytes.NewReader(data))
+}
+
+// ReadRequestedDeprecatedAPIs reads the deprecated apis requested from the
+// apiserver from the metrics saved to fileName, e.g. by
+// kubectl get --raw /metrics
+func ReadRequestedDeprecatedAPIs(fileName string) ([]RequestedDeprecatedAPI, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseRequestedDeprecatedAPIs(file)
+}
+
+// ParseRequestedDeprecatedAPIs parses the apiserver_requested_deprecated_apis
+// gauge of metrics in the prometheus text format
+func ParseRequestedDeprecatedAPIs(metrics io.Reader) ([]RequestedDeprecatedAPI, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(metrics)
+	if err != nil {
+		return nil, err
+	}
+	family, ok := families[requestedDeprecatedAPIsMetric]
+	if !ok {
+		return nil, nil
+	}
+	var apis []RequestedDeprecatedAPI
+	for _, metric := range family.GetMetric() {
+		if metric.GetGauge().GetValue() == 0 {
+			continue
+		}
+		labels := map[string]string{}
+		for _, label := range metric.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		apis = append(apis, RequestedDeprecatedAPI{
+			Group:          labels["group"],
+			Version:        labels["version"],
+			Resource:       labels["resource"],
+			Subresource:    labels["subresource"],
+			RemovedRelease: labels["removed_release"],
+		})
+	}
+	return apis, nil
+}
+
+// RequestedAPIUsage is a deprecated api in active use, cross-checked against
+// the target kubernetes version and the objects of the cluster
+type RequestedAPIUsage struct {
+	RequestedDeprecatedAPI
+	Kind             string
+	LatestAPIVersion string
+	// Removed is set if the api is not served by the target version
+	Removed bool
+	// StoredObjects is the number of objects of the kind of the api which
+	// were scanned, -1 if the kind is unknown
+	StoredObjects int
+}
+
+// AnalyzeRequestedAPIs cross-checks the requested deprecated apis against
+// targetVersion and counts the objects of objs they request. Apis which are
+// requested although no objects of their kind are stored point to clients,
+// e.g. ci jobs or controllers, which still call the old endpoints
+func AnalyzeRequestedAPIs(requested []RequestedDeprecatedAPI, objs []unstructured.Unstructured, kubeC KubeChecker, serverVersion, targetVersion string) []RequestedAPIUsage {
+	if len(requested) == 0 {
+		return nil
+	}
+	sourceResources, _ := kubeC.GetServedResources(serverVersion)
+	targetResources, _ := kubeC.GetServedResources(targetVersion)
+	latestKinds, _ := kubeC.GetKinds(targetVersion)
+	stored := map[string]int{}
+	for _, obj := range objs {
+		stored[strings.ToLower(obj.GetKind())]++
+	}
+	usages := make([]RequestedAPIUsage, 0, len(requested))
+	for _, api := range requested {
+		gvr := api.GroupVersionResource()
+		usage := RequestedAPIUsage{RequestedDeprecatedAPI: api, StoredObjects: -1}
+		if gvk, ok := sourceResources[gvr]; ok {
+			usage.Kind = gvk.Kind
+		} else {
+			usage.Kind = resourceKind(targetResources, gvr)
+		}
+		if _, served := targetResources[gvr]; !served {
+			// the schema of the target is authoritative for the kinds it knows,
+			// otherwise the release the apiserver announces is trusted
+			usage.Removed = len(usage.Kind) > 0 || (len(api.RemovedRelease) > 0 && releaseAtLeast(targetVersion, api.RemovedRelease))
+		}
+		if len(usage.Kind) > 0 {
+			usage.StoredObjects = stored[strings.ToLower(usage.Kind)]
+			if latest := latestGroupVersion(latestKinds, usage.Kind); latest != gvr.GroupVersion().String() {
+				usage.LatestAPIVersion = latest
+			}
+		}
+		usages = append(usages, usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		x, y := usages[i].GroupVersionResource(), usages[j].GroupVersionResource()
+		if x.GroupVersion().String() != y.GroupVersion().String() {
+			return x.GroupVersion().String() < y.GroupVersion().String()
+		}
+		if x.Resource != y.Resource {
+			return x.Resource < y.Resource
+		}
+		return usages[i].Subresource < usages[j].Subresource
+	})
+	return usages
+}
+
+// resourceKind returns the kind of the resource of gvr in resources, in any
+// group, as resources move between groups, e.g. ingresses
+func resourceKind(resources map[schema.GroupVersionResource]schema.GroupVersionKind, gvr schema.GroupVersionResource) string {
+	for resource, gvk := range resources {
+		if resource.Resource == gvr.Resource {
+			return gvk.Kind
+		}
+	}
+	return ""
+}
+
+// RequestedAPIUsageSection reports usages as a section, the apis which are
+// requested although none of their objects are stored are highlighted
+func RequestedAPIUsageSection(usages []RequestedAPIUsage) Section {
+	section := Section{
+		Title:   "Deprecated API Version's in active use",
+		Headers: []string{"Resource", "API Version (Requested)", "Removed Release", "Replace With API Version (Latest Available)", "Status", "Stored Objects"},
+	}
+	for _, usage := range usages {
+		resource := usage.Resource
+		if len(usage.Subresource) > 0 {
+			resource = fmt.Sprintf("%s/%s", resource, usage.Subresource)
+		}
+		status := "deprecated"
+		if usage.Removed {
+			status = "removed"
+		}
+		stored := "unknown"
+		if usage.StoredObjects == 0 {
+			stored = "none, only requested by clients"
+		} else if usage.StoredObjects > 0 {
+			stored = fmt.Sprintf("%d", usage.StoredObjects)
+		}
+		section.Rows = append(section.Rows, []string{resource, usage.GroupVersionResource().GroupVersion().String(), usage.RemovedRelease, usage.LatestAPIVersion, status, stored})
+		section.Highlighted = append(section.Highlighted, usage.StoredObjects == 0)
+	}
+	return section
+}
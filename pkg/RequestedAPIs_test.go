@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"bytes"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const requestedAPIsMetrics = `# HELP apiserver_requested_deprecated_apis [STABLE] Gauge of deprecated APIs that have been requested, broken out by API group, version, resource, subresource, and removed_release.
+# TYPE apiserver_requested_deprecated_apis gauge
+apiserver_requested_deprecated_apis{group="extensions",removed_release="1.22",resource="deployments",subresource="",version="v1beta1"} 1
+apiserver_requested_deprecated_apis{group="extensions",removed_release="1.22",resource="deployments",subresource="scale",version="v1beta1"} 1
+apiserver_requested_deprecated_apis{group="policy",removed_release="1.25",resource="podsecuritypolicies",subresource="",version="v1beta1"} 1
+apiserver_requested_deprecated_apis{group="batch",removed_release="1.25",resource="cronjobs",subresource="",version="v1beta1"} 0
+# HELP apiserver_request_total [STABLE] Counter of apiserver requests.
+# TYPE apiserver_request_total counter
+apiserver_request_total{code="200",resource="deployments",verb="LIST",version="v1"} 12
+`
+
+func TestParseRequestedDeprecatedAPIs(t *testing.T) {
+	got, err := ParseRequestedDeprecatedAPIs(strings.NewReader(requestedAPIsMetrics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RequestedDeprecatedAPI{
+		{Group: "extensions", Version: "v1beta1", Resource: "deployments", RemovedRelease: "1.22"},
+		{Group: "extensions", Version: "v1beta1", Resource: "deployments", Subresource: "scale", RemovedRelease: "1.22"},
+		{Group: "policy", Version: "v1beta1", Resource: "podsecuritypolicies", RemovedRelease: "1.25"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRequestedDeprecatedAPIs() = %v, want %v", got, want)
+	}
+	if got, err := ParseRequestedDeprecatedAPIs(strings.NewReader("# TYPE up gauge\nup 1\n")); err != nil || got != nil {
+		t.Errorf("ParseRequestedDeprecatedAPIs() without the metric = %v, %v", got, err)
+	}
+}
+
+func TestAnalyzeRequestedAPIs(t *testing.T) {
+	kubeC := testKubeChecker(t)
+	requested, err := ParseRequestedDeprecatedAPIs(strings.NewReader(requestedAPIsMetrics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		objs    []unstructured.Unstructured
+		target  string
+		want    []RequestedAPIUsage
+		wantNil bool
+	}{
+		{
+			name:   "requested without stored objects",
+			target: "1.22",
+			objs:   []unstructured.Unstructured{*testObject("v1", "Pod", "default", "web")},
+			want: []RequestedAPIUsage{
+				{RequestedDeprecatedAPI: requested[0], Kind: "Deployment", LatestAPIVersion: "apps/v1", Removed: true, StoredObjects: 0},
+				{RequestedDeprecatedAPI: requested[1], Kind: "Deployment", LatestAPIVersion: "apps/v1", Removed: true, StoredObjects: 0},
+				{RequestedDeprecatedAPI: requested[2], StoredObjects: -1},
+			},
+		},
+		{
+			name:   "stored objects",
+			target: "1.21",
+			objs:   []unstructured.Unstructured{*testObject("apps/v1", "Deployment", "default", "web"), *testObject("apps/v1", "Deployment", "default", "api")},
+			want: []RequestedAPIUsage{
+				{RequestedDeprecatedAPI: requested[0], Kind: "Deployment", LatestAPIVersion: "apps/v1", StoredObjects: 2},
+				{RequestedDeprecatedAPI: requested[1], Kind: "Deployment", LatestAPIVersion: "apps/v1", StoredObjects: 2},
+				{RequestedDeprecatedAPI: requested[2], StoredObjects: -1},
+			},
+		},
+		{
+			name:    "nothing requested",
+			target:  "1.22",
+			wantNil: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apis := requested
+			if tt.wantNil {
+				apis = nil
+			}
+			got := AnalyzeRequestedAPIs(apis, tt.objs, kubeC, "1.21", tt.target)
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("AnalyzeRequestedAPIs() = %v, want nil", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AnalyzeRequestedAPIs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestedAPIUsageSection(t *testing.T) {
+	section := RequestedAPIUsageSection([]RequestedAPIUsage{
+		{RequestedDeprecatedAPI: RequestedDeprecatedAPI{Group: "extensions", Version: "v1beta1", Resource: "deployments", Subresource: "scale", RemovedRelease: "1.22"}, Kind: "Deployment", LatestAPIVersion: "apps/v1", Removed: true},
+		{RequestedDeprecatedAPI: RequestedDeprecatedAPI{Group: "policy", Version: "v1beta1", Resource: "podsecuritypolicies", RemovedRelease: "1.25"}, StoredObjects: -1},
+	})
+	if len(section.Rows) != 2 {
+		t.Fatalf("RequestedAPIUsageSection() rows = %v", section.Rows)
+	}
+	if row := section.Rows[0]; row[0] != "deployments/scale" || row[1] != "extensions/v1beta1" || row[4] != "removed" || row[5] != "none, only requested by clients" {
+		t.Errorf("RequestedAPIUsageSection() row = %v", row)
+	}
+	if row := section.Rows[1]; row[4] != "deprecated" || row[5] != "unknown" {
+		t.Errorf("RequestedAPIUsageSection() row = %v", row)
+	}
+	if !reflect.DeepEqual(section.Highlighted, []bool{true, false}) {
+		t.Errorf("RequestedAPIUsageSection() highlighted = %v, want [true false]", section.Highlighted)
+	}
+
+	buf := new(bytes.Buffer)
+	j := newJSONOutputManager(log.New(buf, "", 0))
+	if err := j.PutSection(section); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, `"title": "Deprecated API Version's in active use"`) || !strings.Contains(out, `"none, only requested by clients"`) || strings.Contains(out, `\u001b`) {
+		t.Errorf("json output of the section = %s", out)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+)
+
+// RuleContext is what the expressions of the rules can see besides the
+// object being checked
+type RuleContext struct {
+	SourceVersion string
+	TargetVersion string
+	Objects       []interface{}
+}
+
+// NewRuleContext creates the context of the rules for the objects scanned
+// together, e.g. the documents of a file or the objects of a cluster
+func NewRuleContext(sourceVersion, targetVersion string, objects []map[string]interface{}) RuleContext {
+	ruleContext := RuleContext{SourceVersion: sourceVersion, TargetVersion: targetVersion}
+	for _, object := range objects {
+		ruleContext.Objects = append(ruleContext.Objects, object)
+	}
+	return ruleContext
+}
+
+var (
+	ruleEnv     *cel.Env
+	ruleEnvErr  error
+	ruleEnvOnce sync.Once
+)
+
+// ruleEnvironment declares the variables of the rule expressions, similar to
+// the ones of ValidatingAdmissionPolicy
+func ruleEnvironment() (*cel.Env, error) {
+	ruleEnvOnce.Do(func() {
+		ruleEnv, ruleEnvErr = cel.NewEnv(
+			cel.Variable("object", cel.DynType),
+			cel.Variable("apiVersion", cel.StringType),
+			cel.Variable("sourceVersion", cel.StringType),
+			cel.Variable("targetVersion", cel.StringType),
+			cel.Variable("objects", cel.ListType(cel.DynType)),
+			ext.Strings(),
+		)
+	})
+	return ruleEnv, ruleEnvErr
+}
+
+func compileExpression(expression string) (cel.Program, error) {
+	env, err := ruleEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("expression must evaluate to bool, found %v", ast.OutputType())
+	}
+	return env.Program(ast)
+}
+
+// evaluateExpression reports object if the expression of the rule is false,
+// failures to evaluate, e.g. a missing field, are reported as well
+func (r *Rule) evaluateExpression(object map[string]interface{}, apiVersion string, ruleContext RuleContext) []*SchemaError {
+	out, _, err := r.program.Eval(map[string]interface{}{
+		"object":        object,
+		"apiVersion":    apiVersion,
+		"sourceVersion": ruleContext.SourceVersion,
+		"targetVersion": ruleContext.TargetVersion,
+		"objects":       ruleContext.Objects,
+	})
+	reason := r.Message
+	if err != nil {
+		reason = fmt.Sprintf("%s: expression failed: %v", r.Message, err)
+	} else if passed, ok := out.Value().(bool); !ok {
+		reason = fmt.Sprintf("%s: expression evaluated to %v instead of bool", r.Message, out.Value())
+	} else if passed {
+		return nil
+	}
+	return []*SchemaError{classifySchemaError(&SchemaError{
+		Value:       object,
+		SchemaField: ruleSchemaField,
+		Reason:      reason,
+		Replacement: r.Replacement,
+		RuleID:      r.ID,
+		Severity:    r.Severity,
+	})}
+}
@@ -0,0 +1,299 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"sigs.k8s.io/yaml"
+)
+
+const ruleSchemaField = "rule"
+
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// RuleSet is the content of a rules file
+type RuleSet struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule is a user defined deprecation or policy. The objects selected by Match
+// are reported if Path exists in them and its value satisfies Condition, or
+// if Expression, a CEL expression, doesn't evaluate to true
+type Rule struct {
+	ID          string         `json:"id"`
+	Match       RuleMatch      `json:"match"`
+	Path        string         `json:"path,omitempty"`
+	Condition   *RuleCondition `json:"condition,omitempty"`
+	Expression  string         `json:"expression,omitempty"`
+	Severity    string         `json:"severity,omitempty"`
+	Message     string         `json:"message"`
+	Replacement string         `json:"replacement,omitempty"`
+
+	segments []string
+	pattern  *regexp.Regexp
+	program  cel.Program
+}
+
+// RuleMatch selects objects by group, version and kind, each being a glob
+// pattern. An empty pattern matches everything, the core group is named core
+type RuleMatch struct {
+	Group   string `json:"group,omitempty"`
+	Version string `json:"version,omitempty"`
+	Kind    string `json:"kind,omitempty"`
+}
+
+// RuleCondition restricts the values of a path a rule is reported for, every
+// condition that is set has to hold
+type RuleCondition struct {
+	Equals    interface{}   `json:"equals,omitempty"`
+	NotEquals interface{}   `json:"notEquals,omitempty"`
+	In        []interface{} `json:"in,omitempty"`
+	Matches   string        `json:"matches,omitempty"`
+}
+
+// LoadRules reads and validates the rules file at filePath
+func LoadRules(filePath string) (*RuleSet, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRules(data)
+}
+
+// ParseRules parses and validates the rules in data, which can be either yaml
+// or json
+func ParseRules(data []byte) (*RuleSet, error) {
+	ruleSet := &RuleSet{}
+	if err := yaml.UnmarshalStrict(data, ruleSet); err != nil {
+		return nil, err
+	}
+	ids := map[string]bool{}
+	for i := range ruleSet.Rules {
+		rule := &ruleSet.Rules[i]
+		if len(rule.ID) == 0 {
+			return nil, fmt.Errorf("rule %d: missing id", i)
+		}
+		if ids[rule.ID] {
+			return nil, fmt.Errorf("rule %s: duplicate id", rule.ID)
+		}
+		ids[rule.ID] = true
+		if len(rule.Severity) == 0 {
+			rule.Severity = SeverityWarning
+		}
+		if rule.Severity != SeverityInfo && rule.Severity != SeverityWarning && rule.Severity != SeverityError {
+			return nil, fmt.Errorf("rule %s: unknown severity %q", rule.ID, rule.Severity)
+		}
+		if len(rule.Expression) > 0 {
+			if len(rule.Path) > 0 || rule.Condition != nil {
+				return nil, fmt.Errorf("rule %s: expression cannot be combined with path or condition", rule.ID)
+			}
+			program, err := compileExpression(rule.Expression)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %v", rule.ID, err)
+			}
+			rule.program = program
+		}
+		rule.segments = splitRulePath(rule.Path)
+		if rule.Condition != nil && len(rule.Condition.Matches) > 0 {
+			pattern, err := regexp.Compile(rule.Condition.Matches)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %v", rule.ID, err)
+			}
+			rule.pattern = pattern
+		}
+	}
+	return ruleSet, nil
+}
+
+// splitRulePath splits a json pointer, e.g. /spec/volumes/0/hostPath, or a
+// glob path, e.g. spec/**/hostPath, into its segments. `*` matches any single
+// segment and `**` any number of them
+func splitRulePath(rulePath string) []string {
+	rulePath = strings.Trim(rulePath, "/")
+	if len(rulePath) == 0 {
+		return nil
+	}
+	segments := strings.Split(rulePath, "/")
+	for i, segment := range segments {
+		segments[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+	}
+	return segments
+}
+
+// Evaluate returns the findings of all the rules matching object
+func (rs *RuleSet) Evaluate(object map[string]interface{}, ruleContext RuleContext) []*SchemaError {
+	if rs == nil {
+		return nil
+	}
+	var errs []*SchemaError
+	for i := range rs.Rules {
+		errs = append(errs, rs.Rules[i].Evaluate(object, ruleContext)...)
+	}
+	return errs
+}
+
+// Evaluate returns a finding for every value in object the rule applies to
+func (r *Rule) Evaluate(object map[string]interface{}, ruleContext RuleContext) []*SchemaError {
+	apiVersion, _ := object["apiVersion"].(string)
+	kind, _ := object["kind"].(string)
+	if !r.Match.matches(apiVersion, kind) {
+		return nil
+	}
+	if r.program != nil {
+		return r.evaluateExpression(object, apiVersion, ruleContext)
+	}
+	var errs []*SchemaError
+	for _, m := range findPaths(object, r.segments, nil) {
+		if !r.Condition.holds(m.value, r.pattern) {
+			continue
+		}
+		errs = append(errs, classifySchemaError(&SchemaError{
+			Value:       m.value,
+			reversePath: reversePointer(m.path),
+			SchemaField: ruleSchemaField,
+			Reason:      r.Message,
+			Replacement: r.Replacement,
+			RuleID:      r.ID,
+			Severity:    r.Severity,
+		}))
+	}
+	return errs
+}
+
+func (m RuleMatch) matches(apiVersion, kind string) bool {
+	group, version := "core", apiVersion
+	if index := strings.LastIndex(apiVersion, "/"); index >= 0 {
+		group, version = apiVersion[:index], apiVersion[index+1:]
+	}
+	return globMatch(m.Group, group) && globMatch(m.Version, version) && globMatch(m.Kind, kind)
+}
+
+func globMatch(pattern, value string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return err == nil && matched
+}
+
+type pathMatch struct {
+	path  []string
+	value interface{}
+}
+
+// findPaths returns the values in value matching the path segments
+func findPaths(value interface{}, segments []string, current []string) []pathMatch {
+	if len(segments) == 0 {
+		return []pathMatch{{path: append([]string(nil), current...), value: value}}
+	}
+	segment := segments[0]
+	var matches []pathMatch
+	if segment == "**" {
+		matches = append(matches, findPaths(value, segments[1:], current)...)
+		for _, child := range children(value) {
+			matches = append(matches, findPaths(child.value, segments, append(current, child.path[0]))...)
+		}
+		return matches
+	}
+	for _, child := range children(value) {
+		if globMatch(segment, child.path[0]) {
+			matches = append(matches, findPaths(child.value, segments[1:], append(current, child.path[0]))...)
+		}
+	}
+	return matches
+}
+
+func children(value interface{}) []pathMatch {
+	var result []pathMatch
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			result = append(result, pathMatch{path: []string{k}, value: child})
+		}
+	case []interface{}:
+		for i, child := range v {
+			result = append(result, pathMatch{path: []string{strconv.Itoa(i)}, value: child})
+		}
+	}
+	return result
+}
+
+func (c *RuleCondition) holds(value interface{}, pattern *regexp.Regexp) bool {
+	if c == nil {
+		return true
+	}
+	if c.Equals != nil && !sameValue(c.Equals, value) {
+		return false
+	}
+	if c.NotEquals != nil && sameValue(c.NotEquals, value) {
+		return false
+	}
+	if len(c.In) > 0 {
+		found := false
+		for _, candidate := range c.In {
+			if sameValue(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if pattern != nil {
+		s, ok := value.(string)
+		if !ok {
+			b, _ := json.Marshal(value)
+			s = string(b)
+		}
+		if !pattern.MatchString(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameValue compares the values decoded from the rules file and the object,
+// both are compared in their json form so numbers of different types match
+func sameValue(expected, actual interface{}) bool {
+	lhs, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+	rhs, err := json.Marshal(actual)
+	if err != nil {
+		return false
+	}
+	var l, r interface{}
+	_ = json.Unmarshal(lhs, &l)
+	_ = json.Unmarshal(rhs, &r)
+	return reflect.DeepEqual(l, r)
+}
@@ -0,0 +1,202 @@
+package pkg
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const testRules = `
+rules:
+- id: no-host-path
+  match:
+    group: core
+    kind: Pod
+  path: spec/volumes/*/hostPath
+  severity: error
+  message: hostPath volumes are not allowed
+  replacement: persistentVolumeClaim
+- id: retired-widget
+  match:
+    group: acme.io
+    version: v1alpha1
+    kind: Widget
+  message: Widget v1alpha1 is retired
+  replacement: acme.io/v1
+- id: latest-tag
+  match:
+    kind: Pod
+  path: /spec/**/image
+  condition:
+    matches: ":latest$"
+  message: images should be pinned
+- id: always-pull
+  match:
+    kind: Pod
+  path: spec/containers/*/imagePullPolicy
+  condition:
+    in: [Always]
+  severity: info
+  message: pulling images on every start
+`
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr bool
+	}{
+		{name: "valid rules", rules: testRules},
+		{name: "missing id", rules: "rules:\n- message: no id\n", wantErr: true},
+		{name: "duplicate id", rules: "rules:\n- id: a\n  message: one\n- id: a\n  message: two\n", wantErr: true},
+		{name: "unknown severity", rules: "rules:\n- id: a\n  severity: fatal\n", wantErr: true},
+		{name: "invalid pattern", rules: "rules:\n- id: a\n  condition:\n    matches: \"(\"\n", wantErr: true},
+		{name: "unknown field", rules: "rules:\n- id: a\n  kind: Pod\n", wantErr: true},
+		{name: "valid expression", rules: "rules:\n- id: a\n  expression: has(object.spec)\n"},
+		{name: "invalid expression", rules: "rules:\n- id: a\n  expression: object.spec.(\n", wantErr: true},
+		{name: "non bool expression", rules: "rules:\n- id: a\n  expression: \"spec\"\n", wantErr: true},
+		{name: "expression with path", rules: "rules:\n- id: a\n  path: spec\n  expression: \"true\"\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRules([]byte(tt.rules))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_LoadRules(t *testing.T) {
+	conf := NewDefaultConfig()
+	if err := conf.LoadRules(); err != nil || conf.Rules != nil {
+		t.Errorf("LoadRules() without rules file = %v, %v", conf.Rules, err)
+	}
+	dir := t.TempDir()
+	conf.RulesFile = filepath.Join(dir, "rules.yaml")
+	if err := conf.LoadRules(); err == nil {
+		t.Errorf("LoadRules() of a missing file returned no error")
+	}
+	if err := ioutil.WriteFile(conf.RulesFile, []byte(testRules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.LoadRules(); err != nil || conf.Rules == nil || len(conf.Rules.Rules) == 0 {
+		t.Errorf("LoadRules() = %v, %v", conf.Rules, err)
+	}
+}
+
+func TestRuleSet_Evaluate(t *testing.T) {
+	ruleSet, err := ParseRules([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		object string
+		want   []string
+	}{
+		{
+			name: "pod",
+			object: `{"apiVersion": "v1", "kind": "Pod", "spec": {
+				"volumes": [{"name": "data", "emptyDir": {}}, {"name": "host", "hostPath": {"path": "/var"}}],
+				"containers": [{"name": "web", "image": "nginx:latest", "imagePullPolicy": "Always"}, {"name": "side", "image": "busybox:1.33", "imagePullPolicy": "IfNotPresent"}]
+			}}`,
+			want: []string{
+				"always-pull spec/containers/0/imagePullPolicy",
+				"latest-tag spec/containers/0/image",
+				"no-host-path spec/volumes/1/hostPath",
+			},
+		},
+		{
+			name:   "pod in another group",
+			object: `{"apiVersion": "acme.io/v1", "kind": "Pod", "spec": {"volumes": [{"name": "host", "hostPath": {"path": "/var"}}]}}`,
+		},
+		{
+			name:   "retired kind",
+			object: `{"apiVersion": "acme.io/v1alpha1", "kind": "Widget", "spec": {}}`,
+			want:   []string{"retired-widget "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object := make(map[string]interface{})
+			if err := json.Unmarshal([]byte(tt.object), &object); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range ruleSet.Evaluate(object, RuleContext{}) {
+				got = append(got, e.RuleID+" "+strings.Join(e.JSONPointer(), "/"))
+			}
+			sort.Strings(got)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+const testExpressionRules = `
+rules:
+- id: pdb-required
+  match:
+    group: apps
+    kind: Deployment
+  expression: >
+    !has(object.spec.replicas) || object.spec.replicas <= 1 ||
+    objects.exists(o, o.kind == 'PodDisruptionBudget' && o.spec.selector == object.spec.selector)
+  severity: error
+  message: replicated deployments must have a PodDisruptionBudget
+- id: lb-annotation
+  match:
+    kind: Service
+  expression: >
+    object.spec.type != 'LoadBalancer' ||
+    'service.beta.kubernetes.io/aws-load-balancer-type' in object.metadata.annotations
+  message: load balancers must declare their type
+- id: ingress-v1
+  match:
+    kind: Ingress
+  expression: apiVersion == 'networking.k8s.io/v1' || targetVersion in ['1.19', '1.20', '1.21']
+  message: Ingress must be migrated to networking.k8s.io/v1
+`
+
+func TestRuleSet_EvaluateExpression(t *testing.T) {
+	ruleSet, err := ParseRules([]byte(testExpressionRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var objects []map[string]interface{}
+	for _, spec := range []string{
+		`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web"}, "spec": {"replicas": 3, "selector": {"matchLabels": {"app": "web"}}}}`,
+		`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "api"}, "spec": {"replicas": 3, "selector": {"matchLabels": {"app": "api"}}}}`,
+		`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "cron"}, "spec": {"replicas": 1, "selector": {"matchLabels": {"app": "cron"}}}}`,
+		`{"apiVersion": "policy/v1", "kind": "PodDisruptionBudget", "metadata": {"name": "web"}, "spec": {"selector": {"matchLabels": {"app": "web"}}}}`,
+		`{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "lb"}, "spec": {"type": "LoadBalancer"}}`,
+		`{"apiVersion": "networking.k8s.io/v1beta1", "kind": "Ingress", "metadata": {"name": "web"}, "spec": {}}`,
+	} {
+		object := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(spec), &object); err != nil {
+			t.Fatal(err)
+		}
+		objects = append(objects, object)
+	}
+	ruleContext := NewRuleContext("1.19", "1.22", objects)
+	var got []string
+	for _, object := range objects {
+		for _, e := range ruleSet.Evaluate(object, ruleContext) {
+			got = append(got, e.RuleID+" "+object["metadata"].(map[string]interface{})["name"].(string))
+		}
+	}
+	sort.Strings(got)
+	want := []string{"ingress-v1 web", "lb-annotation lb", "pdb-required api"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Evaluate() = %v, want %v", got, want)
+	}
+	ruleContext.TargetVersion = "1.20"
+	if errs := ruleSet.Evaluate(objects[5], ruleContext); len(errs) != 0 {
+		t.Errorf("Evaluate() got %d findings for the ingress, want none", len(errs))
+	}
+}
@@ -0,0 +1,199 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position is the location of a finding in the scanned source, the document
+// index starts at 0 while lines and columns start at 1
+type Position struct {
+	File     string `json:"file,omitempty"`
+	Document int    `json:"document"`
+	// Item is the json pointer of the resource within its document, if the
+	// document is a list, e.g. items/3
+	Item   string `json:"item,omitempty"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+func (p *Position) String() string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// ParseError is a document of the scanned source which could not be parsed
+type ParseError struct {
+	Position Position
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.Position.File, e.Position.Line, e.Err)
+}
+
+// Result reports the document which could not be parsed as a result without
+// kind, with the parse error as its finding
+func (e *ParseError) Result() ValidationResult {
+	position := e.Position
+	return ValidationResult{
+		FileName: position.File,
+		Position: &position,
+		ErrorsForOriginal: []*SchemaError{classifySchemaError(&SchemaError{
+			Reason:   e.Err.Error(),
+			Category: CategoryParseError,
+			Position: &position,
+		})},
+	}
+}
+
+// SourceMap maps the json pointers of a yaml document to their positions in
+// the source it was read from
+type SourceMap struct {
+	File     string
+	Document int
+	// keys are the positions of the keys of mapping entries, values those of
+	// the values and sequence items
+	keys   map[string]Position
+	values map[string]Position
+	// prefix is the path of the item of a list the map is scoped to
+	prefix []string
+}
+
+// NewSourceMap builds the SourceMap of the yaml document node of file, lines
+// are offset by lineOffset for documents which were parsed on their own
+func NewSourceMap(file string, document int, node *yaml.Node, lineOffset int) *SourceMap {
+	sm := &SourceMap{
+		File:     file,
+		Document: document,
+		keys:     map[string]Position{},
+		values:   map[string]Position{},
+	}
+	if node != nil && node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node != nil {
+		sm.visit(node, nil, lineOffset)
+	}
+	return sm
+}
+
+// ParseSourceMap parses a single yaml or json document and builds its
+// SourceMap, see NewSourceMap
+func ParseSourceMap(file string, document int, data []byte, lineOffset int) (*SourceMap, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	return NewSourceMap(file, document, &node, lineOffset), nil
+}
+
+func (sm *SourceMap) position(node *yaml.Node, lineOffset int) Position {
+	return Position{File: sm.File, Document: sm.Document, Line: node.Line + lineOffset, Column: node.Column}
+}
+
+func (sm *SourceMap) visit(node *yaml.Node, path []string, lineOffset int) {
+	sm.values[pointerKey(path)] = sm.position(node, lineOffset)
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			childPath := append(append([]string(nil), path...), key.Value)
+			sm.keys[pointerKey(childPath)] = sm.position(key, lineOffset)
+			sm.visit(value, childPath, lineOffset)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			sm.visit(item, append(append([]string(nil), path...), strconv.Itoa(i)), lineOffset)
+		}
+	}
+}
+
+// Lookup returns the position of the json pointer path, or of its closest
+// ancestor present in the document. Mapping entries are located by their key
+func (sm *SourceMap) Lookup(path []string) *Position {
+	if sm == nil {
+		return nil
+	}
+	path = append(append([]string(nil), sm.prefix...), path...)
+	for i := len(path); i >= 0; i-- {
+		key := pointerKey(path[:i])
+		p, ok := sm.keys[key]
+		if !ok {
+			p, ok = sm.values[key]
+		}
+		if ok {
+			p.Item = strings.Join(sm.prefix, "/")
+			return &p
+		}
+	}
+	return nil
+}
+
+// Item returns the SourceMap scoped to the item of a list at path
+func (sm *SourceMap) Item(path []string) *SourceMap {
+	if sm == nil {
+		return nil
+	}
+	item := *sm
+	item.prefix = append(append([]string(nil), sm.prefix...), path...)
+	return &item
+}
+
+// Locate sets the position of err, unknown fields are located by the
+// unsupported property rather than the object declaring it
+func (sm *SourceMap) Locate(err *SchemaError) {
+	if sm == nil || err == nil {
+		return
+	}
+	path := err.JSONPointer()
+	if IsUnknownFieldError(err) {
+		if property, ok := err.Actual.(string); ok {
+			path = append(append([]string(nil), path...), property)
+		}
+	}
+	err.Position = sm.Lookup(path)
+}
+
+// LocateResult sets the position of result and of all its findings
+func (sm *SourceMap) LocateResult(result *ValidationResult) {
+	if sm == nil {
+		return
+	}
+	result.Position = sm.Lookup(nil)
+	for _, errs := range [][]*SchemaError{result.ErrorsForOriginal, result.ErrorsForLatest,
+		result.WarningsForOriginal, result.WarningsForLatest,
+		result.DeprecationForOriginal, result.DeprecationForLatest} {
+		for _, err := range errs {
+			sm.Locate(err)
+		}
+	}
+}
+
+// pointerKey joins path with a separator which cannot be part of a key, as
+// keys like label names may contain slashes
+func pointerKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
@@ -0,0 +1,75 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+const sourceMapPod = `apiVersion: v1
+kind: Pod
+metadata:
+  name: pod
+  labels:
+    app.kubernetes.io/name: pod
+spec:
+  containers:
+  - name: app
+    image: nginx
+    imagePulPolicy: Always
+`
+
+func TestSourceMap_Lookup(t *testing.T) {
+	sm, err := ParseSourceMap("pod.yaml", 1, []byte(sourceMapPod), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		path []string
+		want *Position
+	}{
+		{name: "root", path: nil, want: &Position{File: "pod.yaml", Document: 1, Line: 11, Column: 1}},
+		{name: "key", path: []string{"metadata", "name"}, want: &Position{File: "pod.yaml", Document: 1, Line: 14, Column: 3}},
+		{name: "key with slash", path: []string{"metadata", "labels", "app.kubernetes.io/name"}, want: &Position{File: "pod.yaml", Document: 1, Line: 16, Column: 5}},
+		{name: "list item", path: []string{"spec", "containers", "0"}, want: &Position{File: "pod.yaml", Document: 1, Line: 19, Column: 5}},
+		{name: "missing field is located by its parent", path: []string{"spec", "containers", "0", "ports"}, want: &Position{File: "pod.yaml", Document: 1, Line: 19, Column: 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sm.Lookup(tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lookup() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceMap_Locate(t *testing.T) {
+	sm, err := ParseSourceMap("pod.yaml", 0, []byte(sourceMapPod), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknownField := classifySchemaError(&SchemaError{
+		reversePath: reversePointer([]string{"spec", "containers", "0"}),
+		SchemaField: "properties",
+		Reason:      `property "imagePulPolicy" is unsupported`,
+	})
+	sm.Locate(unknownField)
+	if got := unknownField.Position.String(); got != "11:5" {
+		t.Errorf("Locate() = %v, want the position of the unsupported property 11:5", got)
+	}
+	var nilMap *SourceMap
+	nilMap.Locate(unknownField)
+}
+
+func TestSourceMap_Item(t *testing.T) {
+	list := "apiVersion: v1\nkind: List\nitems:\n- kind: Pod\n  metadata:\n    name: a\n"
+	sm, err := ParseSourceMap("list.yaml", 0, []byte(list), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := sm.Item([]string{"items", "0"})
+	want := &Position{File: "list.yaml", Item: "items/0", Line: 6, Column: 5}
+	if got := item.Lookup([]string{"metadata", "name"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Lookup() = %v, want %v", got, want)
+	}
+}
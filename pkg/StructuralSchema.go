@@ -0,0 +1,247 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	listTypeExtension              = "x-kubernetes-list-type"
+	listMapKeysExtension           = "x-kubernetes-list-map-keys"
+	intOrStringExtension           = "x-kubernetes-int-or-string"
+	embeddedResourceExtension      = "x-kubernetes-embedded-resource"
+	preserveUnknownFieldsExtension = "x-kubernetes-preserve-unknown-fields"
+	listTypeMap                    = "map"
+	listTypeSet                    = "set"
+)
+
+// listTypeAssignments are the lists whose items the apiserver requires to be
+// unique by the given keys but the openapi spec doesn't declare as list maps
+var listTypeAssignments = map[string][]string{
+	"io.k8s.api.core.v1.PodSpec.containers":          {"name"},
+	"io.k8s.api.core.v1.PodSpec.initContainers":      {"name"},
+	"io.k8s.api.core.v1.PodSpec.ephemeralContainers": {"name"},
+	"io.k8s.api.core.v1.PodSpec.volumes":             {"name"},
+	"io.k8s.api.core.v1.Container.ports":             {"containerPort", "protocol"},
+}
+
+// listMapKeyDefaults are the values the apiserver defaults list map keys to
+// when the spec doesn't declare the default
+var listMapKeyDefaults = map[string]interface{}{
+	"protocol": "TCP",
+}
+
+// assignListTypes declares the lists of listTypeAssignments as list maps on
+// the components of doc, lists which already declare a type are left as is
+func assignListTypes(doc *openapi3.T) {
+	for key, mapKeys := range listTypeAssignments {
+		index := strings.LastIndex(key, ".")
+		component, property := key[:index], key[index+1:]
+		ref, ok := doc.Components.Schemas[component]
+		if !ok || ref.Value == nil {
+			continue
+		}
+		prop, ok := ref.Value.Properties[property]
+		if !ok || prop.Value == nil || len(prop.Ref) > 0 || prop.Value.Type != "array" {
+			continue
+		}
+		if _, ok := prop.Value.Extensions[listTypeExtension]; ok {
+			continue
+		}
+		keys, _ := json.Marshal(mapKeys)
+		if prop.Value.Extensions == nil {
+			prop.Value.Extensions = map[string]interface{}{}
+		}
+		prop.Value.Extensions[listTypeExtension] = json.RawMessage(strconv.Quote(listTypeMap))
+		prop.Value.Extensions[listMapKeysExtension] = json.RawMessage(keys)
+	}
+}
+
+// allowsUnknownFields checks whether a schema of an object accepts properties
+// it doesn't declare, either explicitly or by not declaring any
+func allowsUnknownFields(schema *openapi3.Schema) bool {
+	return extensionBool(schema, preserveUnknownFieldsExtension) || len(schema.Properties) == 0 || schema.AdditionalProperties != nil
+}
+
+// disallowUnknownFields rejects the properties not declared by the inline
+// object schemas of a custom resource, which the apiserver would prune. The
+// embedded resources implicitly declare apiVersion, kind and metadata
+func disallowUnknownFields(schema *openapi3.Schema) {
+	if schema == nil {
+		return
+	}
+	if extensionBool(schema, embeddedResourceExtension) && len(schema.Properties) > 0 {
+		for _, field := range []string{"apiVersion", "kind"} {
+			if _, ok := schema.Properties[field]; !ok {
+				schema.Properties[field] = &openapi3.SchemaRef{Value: openapi3.NewStringSchema()}
+			}
+		}
+		if _, ok := schema.Properties["metadata"]; !ok {
+			schema.Properties["metadata"] = &openapi3.SchemaRef{Value: openapi3.NewObjectSchema()}
+		}
+	}
+	if !allowsUnknownFields(schema) {
+		schema.AdditionalPropertiesAllowed = openapi3.BoolPtr(false)
+	}
+	for _, prop := range schema.Properties {
+		if len(prop.Ref) == 0 {
+			disallowUnknownFields(prop.Value)
+		}
+	}
+	if schema.Items != nil && len(schema.Items.Ref) == 0 {
+		disallowUnknownFields(schema.Items.Value)
+	}
+	if schema.AdditionalProperties != nil && len(schema.AdditionalProperties.Ref) == 0 {
+		disallowUnknownFields(schema.AdditionalProperties.Value)
+	}
+}
+
+// validateStructure enforces the structural schema extensions of schema and
+// its properties on value, which the openapi validation doesn't know about
+func validateStructure(schema *openapi3.Schema, value interface{}) []*SchemaError {
+	var errs []*SchemaError
+	visitStructure(schema, value, nil, &errs)
+	return errs
+}
+
+func visitStructure(schema *openapi3.Schema, value interface{}, path []string, errs *[]*SchemaError) {
+	if schema == nil || value == nil {
+		return
+	}
+	if extensionBool(schema, intOrStringExtension) {
+		if !isIntOrStringValue(value) {
+			*errs = append(*errs, structureError(intOrStringExtension, value, path, "must be an integer or a string"))
+		}
+		return
+	}
+	if extensionBool(schema, embeddedResourceExtension) {
+		if object, ok := value.(map[string]interface{}); ok {
+			for _, field := range []string{"apiVersion", "kind"} {
+				if s, _ := object[field].(string); len(s) == 0 {
+					*errs = append(*errs, structureError(embeddedResourceExtension, object, append(append([]string(nil), path...), field), "Required value: must not be empty for embedded resources"))
+				}
+			}
+		}
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			childPath := append(append([]string(nil), path...), k)
+			if s, ok := schema.Properties[k]; ok && s.Value != nil {
+				visitStructure(s.Value, child, childPath, errs)
+			} else if schema.AdditionalProperties != nil && schema.AdditionalProperties.Value != nil {
+				visitStructure(schema.AdditionalProperties.Value, child, childPath, errs)
+			}
+		}
+	case []interface{}:
+		checkListType(schema, v, path, errs)
+		if schema.Items == nil || schema.Items.Value == nil {
+			return
+		}
+		for i, child := range v {
+			visitStructure(schema.Items.Value, child, append(append([]string(nil), path...), strconv.Itoa(i)), errs)
+		}
+	}
+}
+
+// checkListType reports the items of list maps and sets which duplicate an
+// earlier item, by their keys or their whole value respectively
+func checkListType(schema *openapi3.Schema, items []interface{}, path []string, errs *[]*SchemaError) {
+	var listType string
+	if raw, ok := schema.Extensions[listTypeExtension].(json.RawMessage); !ok || json.Unmarshal(raw, &listType) != nil {
+		return
+	}
+	var mapKeys []string
+	if listType == listTypeMap {
+		raw, ok := schema.Extensions[listMapKeysExtension].(json.RawMessage)
+		if !ok || json.Unmarshal(raw, &mapKeys) != nil || len(mapKeys) == 0 {
+			return
+		}
+	} else if listType != listTypeSet {
+		return
+	}
+	seen := map[string]bool{}
+	for i, item := range items {
+		identity := item
+		if listType == listTypeMap {
+			identity = listMapKey(schema, item, mapKeys)
+		}
+		key, err := json.Marshal(identity)
+		if err != nil {
+			continue
+		}
+		if seen[string(key)] {
+			itemPath := append(append([]string(nil), path...), strconv.Itoa(i))
+			*errs = append(*errs, structureError(listTypeExtension, item, itemPath, fmt.Sprintf("Duplicate value: %s", key)))
+			continue
+		}
+		seen[string(key)] = true
+	}
+}
+
+func listMapKey(schema *openapi3.Schema, item interface{}, mapKeys []string) map[string]interface{} {
+	object, _ := item.(map[string]interface{})
+	key := make(map[string]interface{}, len(mapKeys))
+	for _, mapKey := range mapKeys {
+		value, ok := object[mapKey]
+		if !ok && schema.Items != nil && schema.Items.Value != nil {
+			if s, found := schema.Items.Value.Properties[mapKey]; found && s.Value != nil {
+				value = s.Value.Default
+			}
+		}
+		if value == nil {
+			value = listMapKeyDefaults[mapKey]
+		}
+		key[mapKey] = value
+	}
+	return key
+}
+
+func isIntOrStringValue(value interface{}) bool {
+	switch v := value.(type) {
+	case string, int, int32, int64:
+		return true
+	case float64:
+		return v == float64(int64(v))
+	}
+	return false
+}
+
+func extensionBool(schema *openapi3.Schema, extension string) bool {
+	raw, ok := schema.Extensions[extension].(json.RawMessage)
+	if !ok {
+		return false
+	}
+	var flag bool
+	return json.Unmarshal(raw, &flag) == nil && flag
+}
+
+func structureError(extension string, value interface{}, path []string, reason string) *SchemaError {
+	return classifySchemaError(&SchemaError{
+		Value:       value,
+		reversePath: reversePointer(path),
+		SchemaField: extension,
+		Reason:      reason,
+	})
+}
@@ -0,0 +1,120 @@
+package pkg
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+const gadgetCRD = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gadgets.example.io
+spec:
+  group: example.io
+  scope: Cluster
+  names:
+    kind: Gadget
+    plural: gadgets
+  versions:
+  - name: v1
+    served: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              port:
+                x-kubernetes-int-or-string: true
+              tags:
+                type: array
+                x-kubernetes-list-type: set
+                items:
+                  type: string
+              template:
+                type: object
+                x-kubernetes-embedded-resource: true
+                properties:
+                  spec:
+                    type: object
+                    x-kubernetes-preserve-unknown-fields: true
+              config:
+                type: object
+                x-kubernetes-preserve-unknown-fields: true
+`
+
+func TestValidateStructure(t *testing.T) {
+	ks := loadTestSpec(t)
+	crd := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(gadgetCRD), &crd); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.addCRD(crd); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		object string
+		want   []string
+	}{
+		{
+			name: "duplicate containers and ports",
+			object: `{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "web"}, "spec": {"containers": [
+				{"name": "web", "ports": [{"containerPort": 80}, {"containerPort": 80, "protocol": "UDP"}, {"containerPort": 80, "protocol": "TCP"}]},
+				{"name": "web"}
+			]}}`,
+			want: []string{
+				"x-kubernetes-list-type spec/containers/0/ports/2",
+				"x-kubernetes-list-type spec/containers/1",
+			},
+		},
+		{
+			name: "valid custom resource",
+			object: `{"apiVersion": "example.io/v1", "kind": "Gadget", "metadata": {"name": "g"}, "spec": {
+				"port": "http", "tags": ["a", "b"],
+				"template": {"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "p"}, "spec": {"anything": true}},
+				"config": {"free": {"form": 1}}
+			}}`,
+		},
+		{
+			name: "invalid custom resource",
+			object: `{"apiVersion": "example.io/v1", "kind": "Gadget", "metadata": {"name": "g"}, "spec": {
+				"port": 8.5, "tags": ["a", "a"], "unknown": 1,
+				"template": {"kind": "Pod", "spec": {}}
+			}}`,
+			want: []string{
+				"properties spec",
+				"x-kubernetes-embedded-resource spec/template/apiVersion",
+				"x-kubernetes-int-or-string spec/port",
+				"x-kubernetes-list-type spec/tags/1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object := make(map[string]interface{})
+			if err := json.Unmarshal([]byte(tt.object), &object); err != nil {
+				t.Fatal(err)
+			}
+			result, err := ks.ValidateObject(object)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range result.ErrorsForOriginal {
+				got = append(got, e.SchemaField+" "+strings.Join(e.JSONPointer(), "/"))
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ErrorsForOriginal = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
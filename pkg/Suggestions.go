@@ -0,0 +1,153 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// suggestProperty finds the property of the object schema closest to the
+// unsupported property reported by err
+func suggestProperty(err *SchemaError) string {
+	if err.Schema == nil || err.SchemaField != "properties" {
+		return ""
+	}
+	match := quotedProperty.FindStringSubmatch(err.Reason)
+	if match == nil {
+		return ""
+	}
+	candidates := make([]string, 0, len(err.Schema.Properties))
+	for name := range err.Schema.Properties {
+		candidates = append(candidates, name)
+	}
+	return closestMatch(match[1], candidates)
+}
+
+// suggestKind finds the known kind closest to kind
+func (ks *kubeSpec) suggestKind(kind string) string {
+	var candidates []string
+	for _, kis := range ks.kindInfoMap {
+		if len(kis) > 0 {
+			candidates = append(candidates, componentKind(kis[0].ComponentKey))
+		}
+	}
+	return closestMatch(kind, candidates)
+}
+
+// suggestAPIVersion finds the group of kind closest to the group of
+// apiVersion, if that group is unknown for kind. The latest served version of
+// the suggested group is returned
+func (ks *kubeSpec) suggestAPIVersion(apiVersion, kind string) string {
+	group := ""
+	if index := strings.Index(apiVersion, "/"); index >= 0 {
+		group = apiVersion[:index]
+	}
+	versions := map[string]string{}
+	for _, ki := range ks.kindInfoMap[strings.ToLower(kind)] {
+		if ki.Group == group {
+			return ""
+		}
+		if len(ki.RestPath) > 0 {
+			versions[ki.Group] = ki.Version
+		}
+	}
+	candidates := make([]string, 0, len(versions))
+	for g := range versions {
+		candidates = append(candidates, g)
+	}
+	suggestion := closestMatch(group, candidates)
+	if len(suggestion) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s", suggestion, versions[suggestion])
+}
+
+// componentKind returns the kind of a component key, e.g. Deployment for
+// io.k8s.api.apps.v1.Deployment
+func componentKind(component string) string {
+	return component[strings.LastIndex(component, ".")+1:]
+}
+
+// closestMatch returns the candidate with the smallest edit distance to word,
+// if it is close enough to be a likely misspelling. Candidates differing only
+// in case are always suggested
+func closestMatch(word string, candidates []string) string {
+	if len(word) == 0 {
+		return ""
+	}
+	sort.Strings(candidates)
+	best, bestDistance := "", maxSuggestionDistance(word)+1
+	for _, candidate := range candidates {
+		if candidate == word {
+			return ""
+		}
+		distance := levenshtein(strings.ToLower(word), strings.ToLower(candidate))
+		if distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// maxSuggestionDistance allows about one edit for every three characters
+func maxSuggestionDistance(word string) int {
+	distance := len(word) / 3
+	if distance < 1 {
+		distance = 1
+	}
+	return distance
+}
+
+// levenshtein computes the edit distance of a and b, a transposition of two
+// adjacent characters counts as a single edit
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+func minInt(values ...int) int {
+	min := values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+	}
+	return min
+}
@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func Test_closestMatch(t *testing.T) {
+	candidates := []string{"containers", "initContainers", "volumes", "nodeSelector"}
+	tests := []struct {
+		word string
+		want string
+	}{
+		{word: "contaniers", want: "containers"},
+		{word: "containres", want: "containers"},
+		{word: "volume", want: "volumes"},
+		{word: "nodeselector", want: "nodeSelector"},
+		{word: "containers", want: ""},
+		{word: "affinity", want: ""},
+		{word: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := closestMatch(tt.word, candidates); got != tt.want {
+				t.Errorf("closestMatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_levenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "abc", want: 3},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "ab", b: "ba", want: 1},
+		{a: "same", b: "same", want: 0},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	ks := loadTestSpec(t)
+	if got := ks.suggestKind("Deploymnet"); got != "Deployment" {
+		t.Errorf("suggestKind() = %v, want Deployment", got)
+	}
+	if got := ks.suggestKind("Deployment"); got != "" {
+		t.Errorf("suggestKind() = %v, want no suggestion", got)
+	}
+	if got := ks.suggestAPIVersion("aps/v1", "Deployment"); got != "apps/v1" {
+		t.Errorf("suggestAPIVersion() = %v, want apps/v1", got)
+	}
+	if got := ks.suggestAPIVersion("extensions/v1beta1", "Deployment"); got != "" {
+		t.Errorf("suggestAPIVersion() = %v, want no suggestion for a removed version", got)
+	}
+
+	schema := openapi3.NewObjectSchema().WithProperty("containers", openapi3.NewArraySchema())
+	err := &SchemaError{Schema: schema, SchemaField: "properties", Reason: `property "contaniers" is unsupported`}
+	if got := suggestProperty(err); got != "containers" {
+		t.Errorf("suggestProperty() = %v, want containers", got)
+	}
+}
@@ -28,16 +28,6 @@ import (
 
 var SchemaErrorDetailsDisabled = true
 
-// ValidFormat is a type for quickly forcing
-// new formats on the gojsonschema loader
-type ValidFormat struct{}
-
-// IsFormat always returns true and meets the
-// gojsonschema.FormatChecker interface
-func (f ValidFormat) IsFormat(input interface{}) bool {
-	return true
-}
-
 // ValidationResult contains the details from
 // validating a given Kubernetes resource
 type ValidationResult struct {
@@ -46,8 +36,10 @@ type ValidationResult struct {
 	APIVersion             string
 	ValidatedAgainstSchema bool
 	Errors                 []gojsonschema.ResultError
-	ErrorsForOriginal      []*openapi3.SchemaError
-	ErrorsForLatest        []*openapi3.SchemaError
+	ErrorsForOriginal      []*SchemaError
+	ErrorsForLatest        []*SchemaError
+	WarningsForOriginal    []*SchemaError
+	WarningsForLatest      []*SchemaError
 	DeprecationForOriginal []*SchemaError
 	DeprecationForLatest   []*SchemaError
 	ResourceName           string
@@ -56,6 +48,14 @@ type ValidationResult struct {
 	Deprecated             bool
 	LatestAPIVersion       string
 	IsVersionSupported     int
+	// Suggestion is the likely intended kind or apiVersion of a resource whose
+	// kind or group is unknown, e.g. "kind Deployment"
+	Suggestion string
+	// Position is the location of the resource in the scanned source, if known
+	Position *Position
+	// Address is the address of the resource in the configuration managing
+	// it, e.g. the terraform resource address, if known
+	Address string
 }
 
 // VersionKind returns a string representation of this result's apiVersion and kind
@@ -63,6 +63,15 @@ func (v *ValidationResult) VersionKind() string {
 	return v.APIVersion + "/" + v.Kind
 }
 
+// DisplayName returns the name of the k8s resource along with its address,
+// if known
+func (v *ValidationResult) DisplayName() string {
+	if len(v.Address) == 0 {
+		return v.ResourceName
+	}
+	return fmt.Sprintf("%s (%s)", v.ResourceName, v.Address)
+}
+
 // QualifiedName returns a string of the [namespace.]name of the k8s resource
 func (v *ValidationResult) QualifiedName() string {
 	if v.ResourceName == "" {
@@ -74,6 +83,12 @@ func (v *ValidationResult) QualifiedName() string {
 	}
 }
 
+// IsUnknownFieldError checks whether err is raised for a property which is
+// not defined in the schema
+func IsUnknownFieldError(err *SchemaError) bool {
+	return IsErrorClass(err, string(CategoryUnknownField))
+}
+
 type SchemaError struct {
 	Value       interface{}
 	reversePath []string
@@ -81,6 +96,48 @@ type SchemaError struct {
 	SchemaField string
 	Reason      string
 	Origin      error
+	// Replacement is the field or api which should be used instead of
+	// a deprecated one, if known
+	Replacement string
+	// RuleID is the id of the user defined rule which reported the error
+	RuleID string
+	// Severity is the severity of the user defined rule or check which reported
+	// the error
+	Severity string
+	// Category and Code classify the error, see ErrorCategory
+	Category ErrorCategory
+	Code     string
+	// Expected and Actual are the value required by the schema, e.g. a type or
+	// a bound, and the value found in the object, if known
+	Expected interface{}
+	Actual   interface{}
+	// Suggestion is the likely intended property of an unknown field
+	Suggestion string
+	// Position is the location of the field in the scanned source, if known
+	Position *Position
+}
+
+// newSchemaError converts the validation errors of openapi3, whose path
+// cannot be extended outside of that package
+func newSchemaError(err *openapi3.SchemaError) *SchemaError {
+	schemaError := classifySchemaError(&SchemaError{
+		Value:       err.Value,
+		reversePath: reversePointer(err.JSONPointer()),
+		Schema:      err.Schema,
+		SchemaField: err.SchemaField,
+		Reason:      err.Reason,
+		Origin:      err.Origin,
+	})
+	schemaError.Suggestion = suggestProperty(schemaError)
+	return schemaError
+}
+
+// DisplayReason returns the reason of the error along with its suggestion
+func (err *SchemaError) DisplayReason() string {
+	if len(err.Suggestion) == 0 {
+		return err.Reason
+	}
+	return fmt.Sprintf("%s, did you mean %q?", err.Reason, err.Suggestion)
 }
 
 func markSchemaErrorKey(err error, key string) error {
@@ -207,4 +264,5 @@ type KindInfo struct {
 	RestPath     string
 	ComponentKey string
 	IsGA         bool
+	Namespaced   bool
 }
@@ -0,0 +1,197 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"regexp"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+// kubernetesLists and kubernetesRegex are the list and regex functions the
+// apiserver adds to the cel environment of x-kubernetes-validations, see
+// k8s.io/apiserver/pkg/cel/library. The elements of lists are typed at
+// runtime only, as self is dynamically typed
+func kubernetesLists() cel.EnvOption {
+	elem := cel.TypeParamType("T")
+	list := cel.ListType(elem)
+	return cel.Lib(validationRuleLibrary{
+		cel.Function("isSorted",
+			cel.MemberOverload("list_is_sorted", []*cel.Type{list}, cel.BoolType, cel.UnaryBinding(isSorted))),
+		cel.Function("sum",
+			cel.MemberOverload("list_sum", []*cel.Type{list}, elem, cel.UnaryBinding(sum))),
+		cel.Function("min",
+			cel.MemberOverload("list_min", []*cel.Type{list}, elem, cel.UnaryBinding(extremum("min", types.IntNegOne)))),
+		cel.Function("max",
+			cel.MemberOverload("list_max", []*cel.Type{list}, elem, cel.UnaryBinding(extremum("max", types.IntOne)))),
+		cel.Function("indexOf",
+			cel.MemberOverload("list_index_of", []*cel.Type{list, elem}, cel.IntType, cel.BinaryBinding(indexOf(false)))),
+		cel.Function("lastIndexOf",
+			cel.MemberOverload("list_last_index_of", []*cel.Type{list, elem}, cel.IntType, cel.BinaryBinding(indexOf(true)))),
+	})
+}
+
+func kubernetesRegex() cel.EnvOption {
+	return cel.Lib(validationRuleLibrary{
+		cel.Function("find",
+			cel.MemberOverload("string_find_string", []*cel.Type{cel.StringType, cel.StringType}, cel.StringType,
+				cel.BinaryBinding(func(str, pattern ref.Val) ref.Val {
+					re, err := regexp.Compile(string(pattern.(types.String)))
+					if err != nil {
+						return types.NewErr("%v", err)
+					}
+					return types.String(re.FindString(string(str.(types.String))))
+				}))),
+		cel.Function("findAll",
+			cel.MemberOverload("string_find_all_string", []*cel.Type{cel.StringType, cel.StringType}, cel.ListType(cel.StringType),
+				cel.BinaryBinding(func(str, pattern ref.Val) ref.Val {
+					return findAll(str, pattern, types.IntNegOne)
+				})),
+			cel.MemberOverload("string_find_all_string_int", []*cel.Type{cel.StringType, cel.StringType, cel.IntType}, cel.ListType(cel.StringType),
+				cel.FunctionBinding(func(args ...ref.Val) ref.Val {
+					return findAll(args[0], args[1], args[2])
+				}))),
+	})
+}
+
+// validationRuleLibrary adds the functions of a library to a cel environment
+type validationRuleLibrary []cel.EnvOption
+
+func (l validationRuleLibrary) CompileOptions() []cel.EnvOption {
+	return l
+}
+
+func (l validationRuleLibrary) ProgramOptions() []cel.ProgramOption {
+	return nil
+}
+
+func listElements(val ref.Val) ([]ref.Val, ref.Val) {
+	list, ok := val.(traits.Lister)
+	if !ok {
+		return nil, types.MaybeNoSuchOverloadErr(val)
+	}
+	var elements []ref.Val
+	for it := list.Iterator(); it.HasNext() == types.True; {
+		elements = append(elements, it.Next())
+	}
+	return elements, nil
+}
+
+// compare compares a to b, if a is comparable
+func compare(a, b ref.Val) ref.Val {
+	comparer, ok := a.(traits.Comparer)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(a)
+	}
+	return comparer.Compare(b)
+}
+
+func isSorted(val ref.Val) ref.Val {
+	elements, err := listElements(val)
+	if err != nil {
+		return err
+	}
+	for i := 1; i < len(elements); i++ {
+		order := compare(elements[i-1], elements[i])
+		if types.IsError(order) {
+			return order
+		}
+		if order == types.IntOne {
+			return types.False
+		}
+	}
+	return types.True
+}
+
+// sum adds up the elements of a list, the sum of an empty list is 0
+func sum(val ref.Val) ref.Val {
+	elements, err := listElements(val)
+	if err != nil {
+		return err
+	}
+	if len(elements) == 0 {
+		return types.IntZero
+	}
+	total := elements[0]
+	for _, element := range elements[1:] {
+		adder, ok := total.(traits.Adder)
+		if !ok {
+			return types.MaybeNoSuchOverloadErr(total)
+		}
+		total = adder.Add(element)
+		if types.IsError(total) {
+			return total
+		}
+	}
+	return total
+}
+
+// extremum returns the binding of min, for the order -1, or max, for 1
+func extremum(name string, order types.Int) func(ref.Val) ref.Val {
+	return func(val ref.Val) ref.Val {
+		elements, err := listElements(val)
+		if err != nil {
+			return err
+		}
+		if len(elements) == 0 {
+			return types.NewErr("%s called on empty list", name)
+		}
+		result := elements[0]
+		for _, element := range elements[1:] {
+			c := compare(element, result)
+			if types.IsError(c) {
+				return c
+			}
+			if c == order {
+				result = element
+			}
+		}
+		return result
+	}
+}
+
+// indexOf returns the binding of indexOf, or of lastIndexOf if last is set
+func indexOf(last bool) func(ref.Val, ref.Val) ref.Val {
+	return func(val, element ref.Val) ref.Val {
+		elements, err := listElements(val)
+		if err != nil {
+			return err
+		}
+		index := types.IntNegOne
+		for i, e := range elements {
+			if e.Equal(element) == types.True {
+				index = types.Int(i)
+				if !last {
+					break
+				}
+			}
+		}
+		return index
+	}
+}
+
+func findAll(str, pattern, limit ref.Val) ref.Val {
+	re, err := regexp.Compile(string(pattern.(types.String)))
+	if err != nil {
+		return types.NewErr("%v", err)
+	}
+	return types.NewStringList(types.DefaultTypeAdapter, re.FindAllString(string(str.(types.String)), int(limit.(types.Int))))
+}
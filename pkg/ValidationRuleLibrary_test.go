@@ -0,0 +1,44 @@
+package pkg
+
+import "testing"
+
+func TestValidationRuleLibrary(t *testing.T) {
+	self := map[string]interface{}{
+		"ports": []interface{}{int64(80), int64(443), int64(8080)},
+		"names": []interface{}{"web", "api", "web"},
+		"host":  "api-7.example.io",
+	}
+	tests := []struct {
+		rule    string
+		want    bool
+		wantErr bool
+	}{
+		{rule: "self.ports.isSorted()", want: true},
+		{rule: "self.names.isSorted()", want: false},
+		{rule: "self.ports.sum() == 8603", want: true},
+		{rule: "[].sum() == 0", want: true},
+		{rule: "self.ports.min() == 80 && self.ports.max() == 8080", want: true},
+		{rule: "self.names.min() == 'api'", want: true},
+		{rule: "self.names.indexOf('web') == 0 && self.names.lastIndexOf('web') == 2", want: true},
+		{rule: "self.names.indexOf('db') == -1", want: true},
+		{rule: "self.host.find('[0-9]+') == '7'", want: true},
+		{rule: "self.host.findAll('[a-z]+') == ['api', 'example', 'io']", want: true},
+		{rule: "self.host.findAll('[a-z]+', 1) == ['api']", want: true},
+		{rule: "[].max() == 0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			program, err := validationRuleProgram(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, _, err := program.Eval(map[string]interface{}{"self": self})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Eval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && out.Value() != tt.want {
+				t.Errorf("Eval() = %v, want %v", out.Value(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+)
+
+const validationRulesExtension = "x-kubernetes-validations"
+
+// oldSelfReference finds the transition rules, which compare the object to
+// its previous state and hence don't apply to creates
+var oldSelfReference = regexp.MustCompile(`\boldSelf\b`)
+
+// validationRule is an entry of x-kubernetes-validations
+type validationRule struct {
+	Rule              string `json:"rule"`
+	Message           string `json:"message,omitempty"`
+	MessageExpression string `json:"messageExpression,omitempty"`
+	FieldPath         string `json:"fieldPath,omitempty"`
+}
+
+var (
+	validationRuleEnv      *cel.Env
+	validationRuleEnvErr   error
+	validationRuleEnvOnce  sync.Once
+	validationRulePrograms = map[string]cel.Program{}
+	validationRuleErrors   = map[string]error{}
+	validationRuleLock     sync.Mutex
+)
+
+func validationRuleProgram(expression string) (cel.Program, error) {
+	validationRuleEnvOnce.Do(func() {
+		validationRuleEnv, validationRuleEnvErr = cel.NewEnv(cel.Variable("self", cel.DynType), ext.Strings(), kubernetesLists(), kubernetesRegex())
+	})
+	if validationRuleEnvErr != nil {
+		return nil, validationRuleEnvErr
+	}
+	validationRuleLock.Lock()
+	defer validationRuleLock.Unlock()
+	if program, ok := validationRulePrograms[expression]; ok {
+		return program, nil
+	}
+	if err, ok := validationRuleErrors[expression]; ok {
+		return nil, err
+	}
+	ast, issues := validationRuleEnv.Compile(expression)
+	var program cel.Program
+	var err error
+	if issues != nil && issues.Err() != nil {
+		err = issues.Err()
+	} else {
+		program, err = validationRuleEnv.Program(ast)
+	}
+	if err != nil {
+		validationRuleErrors[expression] = err
+		return nil, err
+	}
+	validationRulePrograms[expression] = program
+	return program, nil
+}
+
+// validateRules evaluates the x-kubernetes-validations rules of schema and its
+// properties against value, as the apiserver does on create
+func validateRules(schema *openapi3.Schema, value interface{}) []*SchemaError {
+	var errs []*SchemaError
+	visitValidationRules(schema, value, nil, &errs)
+	return errs
+}
+
+func visitValidationRules(schema *openapi3.Schema, value interface{}, path []string, errs *[]*SchemaError) {
+	if schema == nil || value == nil {
+		return
+	}
+	if raw, ok := schema.Extensions[validationRulesExtension].(json.RawMessage); ok {
+		var rules []validationRule
+		if err := json.Unmarshal(raw, &rules); err == nil {
+			self := celValue(schema, value)
+			for _, rule := range rules {
+				if e := evaluateValidationRule(rule, self, path); e != nil {
+					*errs = append(*errs, e)
+				}
+			}
+		}
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			childPath := append(append([]string(nil), path...), k)
+			if s, ok := schema.Properties[k]; ok && s.Value != nil {
+				visitValidationRules(s.Value, child, childPath, errs)
+			} else if schema.AdditionalProperties != nil && schema.AdditionalProperties.Value != nil {
+				visitValidationRules(schema.AdditionalProperties.Value, child, childPath, errs)
+			}
+		}
+	case []interface{}:
+		if schema.Items == nil || schema.Items.Value == nil {
+			return
+		}
+		for i, child := range v {
+			visitValidationRules(schema.Items.Value, child, append(append([]string(nil), path...), strconv.Itoa(i)), errs)
+		}
+	}
+}
+
+func evaluateValidationRule(rule validationRule, self interface{}, path []string) *SchemaError {
+	if oldSelfReference.MatchString(rule.Rule) {
+		return nil
+	}
+	schemaError := classifySchemaError(&SchemaError{
+		Value:       self,
+		reversePath: reversePointer(path),
+		SchemaField: validationRulesExtension,
+	})
+	if len(rule.FieldPath) > 0 {
+		schemaError.reversePath = reversePointer(append(append([]string(nil), path...), fieldPathToPointer(rule.FieldPath)...))
+	}
+	program, err := validationRuleProgram(rule.Rule)
+	if err != nil {
+		// the apiserver compiled the rule when the crd was created, it uses
+		// functions kubedd does not provide, e.g. url() or quantity()
+		schemaError.Reason = fmt.Sprintf("rule %q could not be compiled: %v", rule.Rule, err)
+		schemaError.Severity = SeverityWarning
+		return schemaError
+	}
+	out, _, err := program.Eval(map[string]interface{}{"self": self})
+	if err != nil {
+		schemaError.Reason = fmt.Sprintf("rule %q could not be evaluated: %v", rule.Rule, err)
+		return schemaError
+	}
+	if passed, ok := out.Value().(bool); ok && passed {
+		return nil
+	}
+	schemaError.Reason = validationRuleMessage(rule, self)
+	return schemaError
+}
+
+func validationRuleMessage(rule validationRule, self interface{}) string {
+	if len(rule.MessageExpression) > 0 {
+		if program, err := validationRuleProgram(rule.MessageExpression); err == nil {
+			if out, _, err := program.Eval(map[string]interface{}{"self": self}); err == nil {
+				if message, ok := out.Value().(string); ok && len(strings.TrimSpace(message)) > 0 {
+					return message
+				}
+			}
+		}
+	}
+	if len(rule.Message) > 0 {
+		return rule.Message
+	}
+	return fmt.Sprintf("failed rule: %s", rule.Rule)
+}
+
+// celValue converts the numbers decoded from json to integers where the schema
+// declares them, so they compare to integer literals in the rules
+func celValue(schema *openapi3.Schema, value interface{}) interface{} {
+	if schema == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case float64:
+		if schema.Type == "integer" && v == float64(int64(v)) {
+			return int64(v)
+		}
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			var childSchema *openapi3.Schema
+			if s, ok := schema.Properties[k]; ok {
+				childSchema = s.Value
+			} else if schema.AdditionalProperties != nil {
+				childSchema = schema.AdditionalProperties.Value
+			}
+			converted[k] = celValue(childSchema, child)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, child := range v {
+			var childSchema *openapi3.Schema
+			if schema.Items != nil {
+				childSchema = schema.Items.Value
+			}
+			converted[i] = celValue(childSchema, child)
+		}
+		return converted
+	}
+	return value
+}
@@ -57,47 +57,80 @@ func (ks *kubeSpec) ValidateObject(object map[string]interface{}) (ValidationRes
 	if err != nil {
 		return validationResult, err
 	}
+	if len(original) == 0 && len(latest) == 0 {
+		// no schema is available for this kind, e.g. custom resources
+		validationResult.ValidatedAgainstSchema = false
+		if suggestion := ks.suggestKind(validationResult.Kind); len(suggestion) > 0 {
+			validationResult.Suggestion = fmt.Sprintf("kind %s", suggestion)
+		}
+		return validationResult, nil
+	}
+	if len(original) == 0 {
+		if suggestion := ks.suggestAPIVersion(validationResult.APIVersion, validationResult.Kind); len(suggestion) > 0 {
+			validationResult.Suggestion = fmt.Sprintf("apiVersion %s", suggestion)
+		}
+	}
 	if len(original) > 0 {
-		var ves []*openapi3.SchemaError
+		var ves []*SchemaError
 		var des []*SchemaError
 		validationError, deprecated := ks.applySchema(object, original)
 		if validationError != nil && len(validationError) > 0 {
 			errs := []error(validationError)
 			for _, e := range errs {
 				if se, ok := e.(*openapi3.SchemaError); ok {
-					ves = append(ves, se)
+					ves = append(ves, newSchemaError(se))
 				} else if de, ok := e.(*SchemaError); ok {
 					des = append(des, de)
 				}
 			}
 		}
-		validationResult.ErrorsForOriginal = ves
+		validationResult.ErrorsForOriginal = append(ves, ks.applySchemaExtensions(object, original)...)
 		validationResult.DeprecationForOriginal = des
 		validationResult.Deprecated = deprecated
 	} else if len(original) == 0 && len(latest) > 0 {
 		validationResult.Deleted = true
 	}
 	if len(latest) > 0 && original != latest {
-		var ves []*openapi3.SchemaError
+		var ves []*SchemaError
 		var des []*SchemaError
 		validationError, _ := ks.applySchema(object, latest)
 		if validationError != nil && len(validationError) > 0 {
 			errs := []error(validationError)
 			for _, e := range errs {
 				if se, ok := e.(*openapi3.SchemaError); ok {
-					ves = append(ves, se)
+					ves = append(ves, newSchemaError(se))
 				} else if de, ok := e.(*SchemaError); ok {
 					des = append(des, de)
 				}
 			}
 		}
-		validationResult.ErrorsForLatest = ves
+		validationResult.ErrorsForLatest = append(ves, ks.applySchemaExtensions(object, latest)...)
 		validationResult.DeprecationForLatest = des
 		validationResult.LatestAPIVersion, err = ks.getKeyForGVFromToken(latest)
 	}
+	ks.applyMetadataValidation(object, original, latest, &validationResult)
 	return validationResult, nil
 }
 
+// applyMetadataValidation validates the metadata against the scope of the kind
+// in the api version the object is migrated to
+func (ks *kubeSpec) applyMetadataValidation(object map[string]interface{}, original, latest string, validationResult *ValidationResult) {
+	target := original
+	if len(latest) > 0 && original != latest {
+		target = latest
+	}
+	namespaced := true
+	if ki, err := ks.kindInfoForComponent(validationResult.Kind, target); err == nil && len(ki.RestPath) > 0 {
+		namespaced = ki.Namespaced
+	}
+	metadataErrors := validateMetadata(object, validationResult.Kind, namespaced)
+	if target == original {
+		validationResult.ErrorsForOriginal = append(validationResult.ErrorsForOriginal, metadataErrors...)
+	} else {
+		validationResult.ErrorsForLatest = append(validationResult.ErrorsForLatest, metadataErrors...)
+	}
+}
+
 func (ks *kubeSpec) populateValidationResult(object map[string]interface{}) (ValidationResult, error) {
 	validationResult := ValidationResult{}
 	namespace := "undefined"
@@ -118,7 +151,9 @@ func (ks *kubeSpec) populateValidationResult(object map[string]interface{}) (Val
 	}
 	name, ok := metadata["name"].(string)
 	if !ok {
-		return validationResult, fmt.Errorf("missing resource name")
+		// objects created with a generated name are reported by their prefix,
+		// a missing name is reported by the metadata validation
+		name, _ = metadata["generateName"].(string)
 	}
 	validationResult.Kind = kind
 	validationResult.APIVersion = apiVersion
@@ -155,7 +190,7 @@ func (ks *kubeSpec) applySchema(object map[string]interface{}, token string) (op
 	//}
 
 	opts := []openapi3.SchemaValidationOption{openapi3.MultiErrors()}
-	depError := VisitJSON(scm, object, SchemaSettings{MultiError: true})
+	depError := VisitJSON(scm, object, SchemaSettings{MultiError: true, SchemaNames: ks.schemaNames, ReleaseVersion: ks.releaseVersion})
 	if len(depError) > 0 {
 		deprecated = true
 	}
@@ -169,6 +204,16 @@ func (ks *kubeSpec) applySchema(object map[string]interface{}, token string) (op
 	return validationError, deprecated
 }
 
+// applySchemaExtensions enforces the structural schema extensions and
+// evaluates the x-kubernetes-validations rules of the schema of token
+func (ks *kubeSpec) applySchemaExtensions(object map[string]interface{}, token string) []*SchemaError {
+	scm, err := ks.schemaLookup(token)
+	if err != nil {
+		return nil
+	}
+	return append(validateStructure(scm, object), validateRules(scm, object)...)
+}
+
 func (ks *kubeSpec) getKeyForGVFromToken(token string) (string, error) {
 	scm, err := ks.schemaLookup(token)
 	if err != nil {
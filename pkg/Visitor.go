@@ -19,29 +19,33 @@ package pkg
 
 import (
 	"github.com/getkin/kin-openapi/openapi3"
-	"strings"
 )
 
 type SchemaSettings struct {
 	MultiError bool
+	// SchemaNames maps component schemas to their names, it is used to look
+	// up the curated deprecation overrides
+	SchemaNames map[*openapi3.Schema]string
+	// ReleaseVersion is the kubernetes release of the schema, the overrides
+	// which are deprecated in a later release are not applied
+	ReleaseVersion string
 }
 
 func VisitJSON(schema *openapi3.Schema, value interface{}, settings SchemaSettings) openapi3.MultiError {
-	return visitJSON(schema, value, settings)
+	var me openapi3.MultiError
+	if info := classifyDeprecation(settings.SchemaNames[schema], "", schema, settings.ReleaseVersion); info.Deprecated {
+		me = append(me, newDeprecationError(schema, info))
+		if !settings.MultiError {
+			return me
+		}
+	}
+	return append(me, visitJSON(schema, value, settings)...)
 }
 
 func visitJSON(schema *openapi3.Schema, value interface{}, settings SchemaSettings) openapi3.MultiError {
 	var me openapi3.MultiError
 	switch value := value.(type) {
 	case nil, bool, float64, string, int64:
-		if strings.Index(strings.ToLower(schema.Description), "deprecated") >= 0 {
-			schemaError := &SchemaError{
-				Value:       "",
-				Schema:      schema,
-				Reason:      schema.Description,
-			}
-			me = append(me, schemaError)
-		}
 		return me
 	case []interface{}:
 		return visitJSONArray(schema, value, settings)
@@ -60,6 +64,9 @@ func visitJSON(schema *openapi3.Schema, value interface{}, settings SchemaSettin
 
 func visitJSONArray(schema *openapi3.Schema, object []interface{}, settings SchemaSettings) openapi3.MultiError {
 	var me openapi3.MultiError
+	if schema.Items == nil || schema.Items.Value == nil {
+		return me
+	}
 	for i, obj := range object {
 		schemaError := visitJSON(schema.Items.Value, obj, settings)
 		if len(schemaError) != 0 {
@@ -72,21 +79,13 @@ func visitJSONArray(schema *openapi3.Schema, object []interface{}, settings Sche
 
 func visitJSONObject(schema *openapi3.Schema, object map[string]interface{}, settings SchemaSettings) openapi3.MultiError {
 	var me openapi3.MultiError
-	if strings.Index(strings.ToLower(schema.Description), "deprecated") >= 0 {
-		schemaError := &SchemaError{
-			Value:       "",
-			Schema:      schema,
-			Reason:      schema.Description,
-		}
-		me = append(me, schemaError)
-		if !settings.MultiError {
-			return me
-		}
-	}
 	for k, v := range object {
-		if s, ok := schema.Properties[k]; ok {
-			//fmt.Printf("found key %s\n", k)
-			schemaError := visitJSON(s.Value, v, settings)
+		if s, ok := schema.Properties[k]; ok && s.Value != nil {
+			var schemaError openapi3.MultiError
+			if info := classifyDeprecation(settings.SchemaNames[schema], k, s.Value, settings.ReleaseVersion); info.Deprecated {
+				schemaError = append(schemaError, newDeprecationError(s.Value, info))
+			}
+			schemaError = append(schemaError, visitJSON(s.Value, v, settings)...)
 			if len(schemaError) != 0 {
 				markSchemaErrorKey(schemaError, k)
 				me = append(me, schemaError...)
@@ -94,9 +93,17 @@ func visitJSONObject(schema *openapi3.Schema, object map[string]interface{}, set
 					return me
 				}
 			}
-		} else {
-			//fmt.Printf("not found key %s\n", k)
 		}
 	}
 	return me
 }
+
+func newDeprecationError(schema *openapi3.Schema, info DeprecationInfo) *SchemaError {
+	return classifySchemaError(&SchemaError{
+		Value:       "",
+		Schema:      schema,
+		SchemaField: deprecationSchemaField,
+		Reason:      info.Message,
+		Replacement: info.Replacement,
+	})
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright (c) 2021 Devtron Labs
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+const wellKnownLabelSchemaField = "wellKnownLabel"
+
+const (
+	LabelKey      = "label"
+	AnnotationKey = "annotation"
+)
+
+// DeprecatedLabel is an entry of the catalog of well-known labels and
+// annotations which are deprecated or no longer honored by kubernetes. Keys
+// ending with `*` match every key with that prefix
+type DeprecatedLabel struct {
+	Key          string
+	Type         string
+	DeprecatedIn string
+	RemovedIn    string
+	Replacement  string
+}
+
+var deprecatedLabels = []DeprecatedLabel{
+	{Key: "beta.kubernetes.io/os", Type: LabelKey, DeprecatedIn: "1.14", Replacement: "kubernetes.io/os"},
+	{Key: "beta.kubernetes.io/arch", Type: LabelKey, DeprecatedIn: "1.14", Replacement: "kubernetes.io/arch"},
+	{Key: "beta.kubernetes.io/instance-type", Type: LabelKey, DeprecatedIn: "1.17", Replacement: "node.kubernetes.io/instance-type"},
+	{Key: "failure-domain.beta.kubernetes.io/zone", Type: LabelKey, DeprecatedIn: "1.17", Replacement: "topology.kubernetes.io/zone"},
+	{Key: "failure-domain.beta.kubernetes.io/region", Type: LabelKey, DeprecatedIn: "1.17", Replacement: "topology.kubernetes.io/region"},
+	{Key: "node-role.kubernetes.io/master", Type: LabelKey, DeprecatedIn: "1.20", RemovedIn: "1.24", Replacement: "node-role.kubernetes.io/control-plane"},
+	{Key: "scheduler.alpha.kubernetes.io/critical-pod", Type: AnnotationKey, DeprecatedIn: "1.13", RemovedIn: "1.16", Replacement: "spec.priorityClassName"},
+	{Key: "service.alpha.kubernetes.io/tolerate-unready-endpoints", Type: AnnotationKey, DeprecatedIn: "1.11", Replacement: "spec.publishNotReadyAddresses"},
+	{Key: "volume.beta.kubernetes.io/storage-class", Type: AnnotationKey, DeprecatedIn: "1.6", Replacement: "spec.storageClassName"},
+	{Key: "volume.beta.kubernetes.io/storage-provisioner", Type: AnnotationKey, DeprecatedIn: "1.23", Replacement: "volume.kubernetes.io/storage-provisioner"},
+	{Key: "kubernetes.io/ingress.class", Type: AnnotationKey, DeprecatedIn: "1.18", Replacement: "spec.ingressClassName"},
+	{Key: "seccomp.security.alpha.kubernetes.io/pod", Type: AnnotationKey, DeprecatedIn: "1.19", RemovedIn: "1.27", Replacement: "spec.securityContext.seccompProfile"},
+	{Key: "container.seccomp.security.alpha.kubernetes.io/*", Type: AnnotationKey, DeprecatedIn: "1.19", RemovedIn: "1.27", Replacement: "spec.containers[*].securityContext.seccompProfile"},
+	{Key: "container.apparmor.security.beta.kubernetes.io/*", Type: AnnotationKey, DeprecatedIn: "1.30", Replacement: "spec.containers[*].securityContext.appArmorProfile"},
+	{Key: "service.kubernetes.io/topology-aware-hints", Type: AnnotationKey, DeprecatedIn: "1.27", Replacement: "service.kubernetes.io/topology-mode"},
+}
+
+// RegisterDeprecatedLabel is used to add an entry to the catalog of
+// deprecated well-known labels and annotations
+func RegisterDeprecatedLabel(label DeprecatedLabel) {
+	deprecatedLabels = append(deprecatedLabels, label)
+}
+
+// labelMapKeys are the properties whose keys are label keys
+var labelMapKeys = map[string]bool{
+	"labels":       true,
+	"nodeSelector": true,
+	"matchLabels":  true,
+}
+
+// labelListKeys are the properties whose items are label keys
+var labelListKeys = map[string]bool{
+	"matchLabelKeys":    true,
+	"mismatchLabelKeys": true,
+}
+
+// CheckWellKnownLabels reports the deprecated well-known labels and annotations
+// used in metadata, selectors, node selectors, affinity terms and topology
+// spread constraints of object, as of the kubernetes releaseVersion
+func CheckWellKnownLabels(object map[string]interface{}, releaseVersion string) []*SchemaError {
+	var errs []*SchemaError
+	check := func(key, keyType string, path []string) {
+		label, ok := findDeprecatedLabel(key, keyType)
+		if !ok || !releaseAtLeast(releaseVersion, label.DeprecatedIn) {
+			return
+		}
+		reason := fmt.Sprintf("%s %s is deprecated since %s", keyType, key, label.DeprecatedIn)
+		if len(label.RemovedIn) > 0 {
+			if releaseAtLeast(releaseVersion, label.RemovedIn) {
+				reason = fmt.Sprintf("%s %s is deprecated since %s and no longer honored since %s", keyType, key, label.DeprecatedIn, label.RemovedIn)
+			} else {
+				reason = fmt.Sprintf("%s %s is deprecated since %s and will no longer be honored from %s", keyType, key, label.DeprecatedIn, label.RemovedIn)
+			}
+		}
+		errs = append(errs, classifySchemaError(&SchemaError{
+			Value:       key,
+			reversePath: reversePointer(path),
+			SchemaField: wellKnownLabelSchemaField,
+			Reason:      reason,
+			Replacement: label.Replacement,
+		}))
+	}
+	visitLabels(object, nil, check)
+	return errs
+}
+
+func visitLabels(value interface{}, path []string, check func(key, keyType string, path []string)) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			childPath := append(append([]string(nil), path...), k)
+			switch {
+			case k == "status" || k == "managedFields":
+				continue
+			case k == "annotations":
+				forEachMapKey(child, childPath, AnnotationKey, check)
+			case labelMapKeys[k] || (k == "selector" && isStringMap(child)):
+				forEachMapKey(child, childPath, LabelKey, check)
+			case k == "topologyKey":
+				if key, ok := child.(string); ok {
+					check(key, LabelKey, childPath)
+				}
+			case k == "matchExpressions" || labelListKeys[k]:
+				items, ok := child.([]interface{})
+				if !ok {
+					continue
+				}
+				for i, item := range items {
+					itemPath := append(append([]string(nil), childPath...), strconv.Itoa(i))
+					if key, ok := item.(string); ok {
+						check(key, LabelKey, itemPath)
+					} else if expression, ok := item.(map[string]interface{}); ok {
+						if key, ok := expression["key"].(string); ok {
+							check(key, LabelKey, append(itemPath, "key"))
+						}
+					}
+				}
+			default:
+				visitLabels(child, childPath, check)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			visitLabels(child, append(append([]string(nil), path...), strconv.Itoa(i)), check)
+		}
+	}
+}
+
+func forEachMapKey(value interface{}, path []string, keyType string, check func(key, keyType string, path []string)) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for key := range m {
+		check(key, keyType, append(append([]string(nil), path...), key))
+	}
+}
+
+// isStringMap distinguishes the selectors of services, which are plain label
+// maps, from label selectors
+func isStringMap(value interface{}) bool {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for _, v := range m {
+		if _, ok := v.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func findDeprecatedLabel(key, keyType string) (DeprecatedLabel, bool) {
+	for _, label := range deprecatedLabels {
+		if label.Type == keyType && RegexMatch(key, label.Key) {
+			return label, true
+		}
+	}
+	return DeprecatedLabel{}, false
+}
+
+var releasePattern = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// releaseAtLeast checks whether kubernetes releaseVersion is the same or newer
+// than version. Releases which cannot be parsed, e.g. master, are the newest
+func releaseAtLeast(releaseVersion, version string) bool {
+	release, ok := parseRelease(releaseVersion)
+	if !ok {
+		return true
+	}
+	other, ok := parseRelease(version)
+	if !ok {
+		return false
+	}
+	if release[0] != other[0] {
+		return release[0] > other[0]
+	}
+	return release[1] >= other[1]
+}
+
+func parseRelease(version string) ([2]int, bool) {
+	match := releasePattern.FindStringSubmatch(version)
+	if match == nil {
+		return [2]int{}, false
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	return [2]int{major, minor}, true
+}
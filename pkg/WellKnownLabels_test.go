@@ -0,0 +1,114 @@
+package pkg
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const labelledDeployment = `
+{
+  "apiVersion": "apps/v1",
+  "kind": "Deployment",
+  "metadata": {
+    "name": "web",
+    "annotations": {"kubernetes.io/ingress.class": "nginx"}
+  },
+  "spec": {
+    "selector": {
+      "matchLabels": {"app": "web"},
+      "matchExpressions": [{"key": "failure-domain.beta.kubernetes.io/zone", "operator": "Exists"}]
+    },
+    "template": {
+      "metadata": {
+        "labels": {"app": "web"},
+        "annotations": {
+          "seccomp.security.alpha.kubernetes.io/pod": "runtime/default",
+          "container.seccomp.security.alpha.kubernetes.io/web": "runtime/default"
+        }
+      },
+      "spec": {
+        "nodeSelector": {"beta.kubernetes.io/os": "linux"},
+        "topologySpreadConstraints": [{"topologyKey": "failure-domain.beta.kubernetes.io/zone", "maxSkew": 1}],
+        "affinity": {"nodeAffinity": {"requiredDuringSchedulingIgnoredDuringExecution": {"nodeSelectorTerms": [
+          {"matchExpressions": [{"key": "beta.kubernetes.io/arch", "operator": "In", "values": ["amd64"]}]}
+        ]}}}
+      }
+    }
+  },
+  "status": {"labels": {"beta.kubernetes.io/os": "linux"}}
+}`
+
+func TestCheckWellKnownLabels(t *testing.T) {
+	object := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(labelledDeployment), &object); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name           string
+		releaseVersion string
+		want           []string
+	}{
+		{
+			name:           "before any deprecation",
+			releaseVersion: "1.12",
+		},
+		{
+			name:           "node labels deprecated",
+			releaseVersion: "1.16",
+			want: []string{
+				"spec/template/spec/affinity/nodeAffinity/requiredDuringSchedulingIgnoredDuringExecution/nodeSelectorTerms/0/matchExpressions/0/key",
+				"spec/template/spec/nodeSelector/beta.kubernetes.io/os",
+			},
+		},
+		{
+			name:           "master is the newest release",
+			releaseVersion: "master",
+			want: []string{
+				"metadata/annotations/kubernetes.io/ingress.class",
+				"spec/selector/matchExpressions/0/key",
+				"spec/template/metadata/annotations/container.seccomp.security.alpha.kubernetes.io/web",
+				"spec/template/metadata/annotations/seccomp.security.alpha.kubernetes.io/pod",
+				"spec/template/spec/affinity/nodeAffinity/requiredDuringSchedulingIgnoredDuringExecution/nodeSelectorTerms/0/matchExpressions/0/key",
+				"spec/template/spec/nodeSelector/beta.kubernetes.io/os",
+				"spec/template/spec/topologySpreadConstraints/0/topologyKey",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range CheckWellKnownLabels(object, tt.releaseVersion) {
+				if len(e.Replacement) == 0 {
+					t.Errorf("CheckWellKnownLabels() no replacement for %s", e.Value)
+				}
+				got = append(got, strings.Join(e.JSONPointer(), "/"))
+			}
+			sort.Strings(got)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("CheckWellKnownLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_releaseAtLeast(t *testing.T) {
+	tests := []struct {
+		releaseVersion string
+		version        string
+		want           bool
+	}{
+		{releaseVersion: "1.22", version: "1.22", want: true},
+		{releaseVersion: "1.9", version: "1.17", want: false},
+		{releaseVersion: "v1.25.3", version: "1.24", want: true},
+		{releaseVersion: "master", version: "1.30", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.releaseVersion+"/"+tt.version, func(t *testing.T) {
+			if got := releaseAtLeast(tt.releaseVersion, tt.version); got != tt.want {
+				t.Errorf("releaseAtLeast() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"github.com/fatih/color"
 	multierror "github.com/hashicorp/go-multierror"
+	"os"
 	"strings"
 )
 
@@ -29,9 +30,11 @@ func Success(message ...string) {
 	fmt.Printf("%s - %v\n", green("PASS"), strings.Join(message, " "))
 }
 
+// Warn, Error and Debug write to stderr, so they don't mix with the json or
+// tap output of the results on stdout
 func Warn(message ...string) {
 	yellow := color.New(color.FgYellow).SprintFunc()
-	fmt.Printf("%s - %v\n", yellow("WARN"), strings.Join(message, " "))
+	fmt.Fprintf(os.Stderr, "%s - %v\n", yellow("WARN"), strings.Join(message, " "))
 }
 
 func Error(message error) {
@@ -41,11 +44,11 @@ func Error(message error) {
 		}
 	} else {
 		red := color.New(color.FgRed).SprintFunc()
-		fmt.Printf("%s - %v\n", red("ERR "), message)
+		fmt.Fprintf(os.Stderr, "%s - %v\n", red("ERR "), message)
 	}
 }
 
 func Debug(message ...string) {
 	yellow := color.New(color.FgWhite).SprintFunc()
-	fmt.Printf("%s - %v\n", yellow("DEBUG"), strings.Join(message, " "))
+	fmt.Fprintf(os.Stderr, "%s - %v\n", yellow("DEBUG"), strings.Join(message, " "))
 }